@@ -0,0 +1,66 @@
+package router
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// AccessLog returns a Middleware that logs every request to logger once it's served.
+// If logger is nil, slog.Default() is used. The slog.Handler behind the logger is the log sink.
+//
+// Each record has the method, the matched route pattern (instead of the raw path, to keep cardinality low),
+// the route params, the response status and size, the latency and the request ID,
+// as set by WithRequestID or, without it, the X-Request-ID header.
+// Responses with a 5xx status are logged at error level, everything else at info level.
+func AccessLog(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			w := wrapWriter(rw)
+
+			defer func() {
+				// Log panicking requests as 500 before passing the panic on
+				v := recover()
+
+				status := w.status
+				if status == 0 {
+					status = http.StatusOK
+					if v != nil {
+						status = http.StatusInternalServerError
+					}
+				}
+
+				level := slog.LevelInfo
+				if status >= 500 {
+					level = slog.LevelError
+				}
+
+				id := RequestID(req)
+				if id == "" {
+					id = req.Header.Get("X-Request-ID")
+				}
+
+				logger.LogAttrs(req.Context(), level, "request",
+					slog.String("method", req.Method),
+					slog.String("route", Pattern(req)),
+					slog.Any("params", Params(req)),
+					slog.Int("status", status),
+					slog.Int64("bytes", w.size),
+					slog.Duration("latency", time.Since(start)),
+					slog.String("request_id", id),
+				)
+
+				if v != nil {
+					panic(v)
+				}
+			}()
+
+			next.ServeHTTP(w.writer(), req)
+		})
+	}
+}
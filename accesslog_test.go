@@ -0,0 +1,72 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	r := New("/api")
+	r.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Hello"))
+	}))
+
+	d := Build(r)
+	d.Wrap(AccessLog(logger))
+
+	req := httptest.NewRequest("POST", "/api/users/42", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	d.ServeHTTP(httptest.NewRecorder(), req)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Log record should be valid JSON: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"level":      "INFO",
+		"method":     "POST",
+		"route":      "/api/users/:id",
+		"status":     float64(201),
+		"bytes":      float64(5),
+		"request_id": "abc",
+	}
+	for k, v := range expected {
+		if record[k] != v {
+			t.Errorf("Log record %s should be %v. Got %v", k, v, record[k])
+		}
+	}
+	if params, ok := record["params"].(map[string]interface{}); !ok || params["id"] != "42" {
+		t.Errorf("Log record params should have id 42. Got %v", record["params"])
+	}
+	if _, ok := record["latency"]; !ok {
+		t.Error("Log record should have the latency")
+	}
+}
+
+func TestAccessLogServerError(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := slog.New(slog.NewJSONHandler(buf, nil))
+
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	r.Wrap(AccessLog(logger))
+
+	Build(r).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	var record map[string]interface{}
+	json.Unmarshal(buf.Bytes(), &record)
+	if record["level"] != "ERROR" {
+		t.Errorf("5xx responses should be logged at error level. Got %v", record["level"])
+	}
+}
@@ -0,0 +1,137 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+type principalKey struct{}
+
+// Principal returns the authenticated principal stored by the authentication Middleware
+// (BasicAuth, BearerAuth, APIKeyAuth), or nil if the request isn't authenticated.
+func Principal(req *http.Request) interface{} {
+	return req.Context().Value(principalKey{})
+}
+
+// SetPrincipal stores the authenticated principal in the request context.
+// The request is updated in place, so the principal is also available to the middleware wrapping the current one.
+// It's meant for custom authentication middleware.
+func SetPrincipal(req *http.Request, principal interface{}) {
+	*req = *req.WithContext(context.WithValue(req.Context(), principalKey{}, principal))
+}
+
+// BasicAuth returns a Middleware implementing HTTP Basic authentication against a map of usernames and passwords.
+// Credentials are compared in constant time. The principal is the username.
+func BasicAuth(realm string, users map[string]string) Middleware {
+	// Hash credentials so the comparison time doesn't depend on their length
+	hashed := make(map[string][32]byte, len(users))
+	for user, pass := range users {
+		hashed[user] = sha256.Sum256([]byte(pass))
+	}
+	dummy := sha256.Sum256([]byte{})
+
+	return BasicAuthFunc(realm, func(user, pass string) bool {
+		expected, ok := hashed[user]
+		if !ok {
+			// Keep timing similar for unknown users
+			expected = dummy
+		}
+		given := sha256.Sum256([]byte(pass))
+
+		return subtle.ConstantTimeCompare(given[:], expected[:]) == 1 && ok
+	})
+}
+
+// BasicAuthFunc returns a Middleware implementing HTTP Basic authentication with a custom credentials check.
+// The principal is the username.
+func BasicAuthFunc(realm string, check func(user, pass string) bool) Middleware {
+	challenge := `Basic realm="` + strings.Replace(realm, `"`, `\"`, -1) + `", charset="UTF-8"`
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user, pass, ok := req.BasicAuth()
+			if !ok || !check(user, pass) {
+				w.Header().Set("WWW-Authenticate", challenge)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			SetPrincipal(req, user)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// TokenVerifier validates a token or key and returns the principal it belongs to.
+type TokenVerifier func(ctx context.Context, token string) (principal interface{}, err error)
+
+// BearerAuth returns a Middleware validating "Authorization: Bearer <token>" headers with verify.
+// The principal is the one returned by verify.
+func BearerAuth(verify TokenVerifier) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token, ok := bearerToken(req)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			principal, err := verify(req.Context(), token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			SetPrincipal(req, principal)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// bearerToken returns the token from the Authorization header, if it uses the Bearer scheme.
+func bearerToken(req *http.Request) (string, bool) {
+	auth := req.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(auth[7:])
+
+	return token, token != ""
+}
+
+// APIKeyAuth returns a Middleware validating API keys with verify.
+// The key is taken from the header, if not empty, or else from the query parameter.
+// The principal is the one returned by verify.
+func APIKeyAuth(header, query string, verify TokenVerifier) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			key := ""
+			if header != "" {
+				key = req.Header.Get(header)
+			}
+			if key == "" && query != "" {
+				key = req.URL.Query().Get(query)
+			}
+
+			if key == "" {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			principal, err := verify(req.Context(), key)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			SetPrincipal(req, principal)
+			next.ServeHTTP(w, req)
+		})
+	}
+}
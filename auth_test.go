@@ -0,0 +1,117 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func principalHandler(w http.ResponseWriter, r *http.Request) {
+	if p, ok := Principal(r).(string); ok {
+		w.Write([]byte(p))
+	}
+}
+
+func verifyToken(ctx context.Context, token string) (interface{}, error) {
+	if token == "secret" {
+		return "joe", nil
+	}
+
+	return nil, errors.New("invalid token")
+}
+
+func TestBasicAuth(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(principalHandler))
+	r.Wrap(BasicAuth("Admin area", map[string]string{"joe": "pass"}))
+
+	d := Build(r)
+
+	for _, c := range []struct {
+		user, pass string
+		code       int
+	}{
+		{"joe", "pass", http.StatusOK},
+		{"joe", "wrong", http.StatusUnauthorized},
+		{"other", "pass", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		if c.user != "" {
+			req.SetBasicAuth(c.user, c.pass)
+		}
+		d.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("%s:%s should respond %d. Got %d", c.user, c.pass, c.code, w.Code)
+		}
+		if c.code == http.StatusOK && w.Body.String() != "joe" {
+			t.Errorf("Principal should be 'joe'. Got %s", w.Body.String())
+		}
+		if c.code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != `Basic realm="Admin area", charset="UTF-8"` {
+			t.Errorf("WWW-Authenticate header isn't as expected. Got %s", w.Header().Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestBearerAuth(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(principalHandler)).Wrap(BearerAuth(verifyToken))
+	r.Add("/public", http.HandlerFunc(handler))
+
+	d := Build(r)
+
+	for auth, code := range map[string]int{
+		"Bearer secret": http.StatusOK,
+		"bearer secret": http.StatusOK,
+		"Bearer wrong":  http.StatusUnauthorized,
+		"Basic secret":  http.StatusUnauthorized,
+		"":              http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", auth)
+		d.ServeHTTP(w, req)
+
+		if w.Code != code {
+			t.Errorf("'%s' should respond %d. Got %d", auth, code, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/public", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Routes without the middleware shouldn't require authentication. Got %d", w.Code)
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(principalHandler))
+	r.Wrap(APIKeyAuth("X-API-Key", "api_key", verifyToken))
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Key", "secret")
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "joe" {
+		t.Errorf("API key in header should authenticate 'joe'. Got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/?api_key=secret", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "joe" {
+		t.Errorf("API key in query should authenticate 'joe'. Got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/?api_key=wrong", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Invalid API key should respond 401. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,74 @@
+package router
+
+import (
+	"context"
+	"net/http"
+)
+
+type authorizerKey struct{}
+
+// Authorization holds what an Authorizer needs to decide on a request to a route with requirements.
+type Authorization struct {
+	// Principal stored by the authentication Middleware, nil if the request isn't authenticated
+	Principal interface{}
+
+	// Pattern of the route matched, e.g. "/:tenant/users/:id"
+	Pattern string
+
+	// Params of the route matched
+	Params map[string]string
+
+	// Requirements set with Route.Require
+	Requirements []string
+}
+
+// Authorizer decides if a request can be served by a route with requirements.
+// A non nil error denies the request with 403 Forbidden.
+type Authorizer interface {
+	Authorize(ctx context.Context, a *Authorization) error
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface.
+type AuthorizerFunc func(ctx context.Context, a *Authorization) error
+
+// Authorize calls f(ctx, a).
+func (f AuthorizerFunc) Authorize(ctx context.Context, a *Authorization) error {
+	return f(ctx, a)
+}
+
+// setAuthorizer stores the Dispatcher Authorizer in the request context, so routes can enforce their requirements.
+func setAuthorizer(req *http.Request, a Authorizer) {
+	*req = *req.WithContext(context.WithValue(req.Context(), authorizerKey{}, a))
+}
+
+// authorize is the Middleware enforcing the route requirements right before the route handler,
+// so the principal set by any authentication Middleware is available.
+// Requests without principal get 401 Unauthorized. Without Authorizer every request is denied.
+func (rt *Route) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		principal := Principal(req)
+		if principal == nil {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		a, ok := req.Context().Value(authorizerKey{}).(Authorizer)
+		if !ok || a == nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		err := a.Authorize(req.Context(), &Authorization{
+			Principal:    principal,
+			Pattern:      rt.pattern,
+			Params:       Params(req),
+			Requirements: append([]string{}, rt.requires...),
+		})
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
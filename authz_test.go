@@ -0,0 +1,71 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteRequire(t *testing.T) {
+	var got *Authorization
+
+	r := New("/")
+	r.Add("/:tenant/users", http.HandlerFunc(handler)).Methods("POST").Require("users:write")
+	r.Add("/:tenant/users", http.HandlerFunc(handler)).Methods("GET")
+	r.Wrap(BearerAuth(func(ctx context.Context, token string) (interface{}, error) {
+		// Token is the tenant the principal belongs to
+		return token, nil
+	}))
+
+	d := Build(r)
+	d.Authorize(AuthorizerFunc(func(ctx context.Context, a *Authorization) error {
+		got = a
+		if a.Params["tenant"] != a.Principal.(string) {
+			return errors.New("wrong tenant")
+		}
+
+		return nil
+	}))
+
+	for _, c := range []struct {
+		method, path, token string
+		code                int
+	}{
+		{"POST", "/acme/users", "acme", http.StatusOK},
+		{"POST", "/acme/users", "other", http.StatusForbidden},
+		{"POST", "/acme/users", "", http.StatusUnauthorized},
+		{"GET", "/acme/users", "other", http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		d.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("%s %s with token '%s' should respond %d. Got %d", c.method, c.path, c.token, c.code, w.Code)
+		}
+	}
+
+	if got == nil || got.Pattern != "/:tenant/users" || len(got.Requirements) != 1 || got.Requirements[0] != "users:write" {
+		t.Errorf("Authorizer didn't receive the route details. Got %+v", got)
+	}
+}
+
+func TestRouteRequireWithoutAuthorizer(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(handler)).Require("admin")
+	r.Wrap(BearerAuth(verifyToken))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	Build(r).ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Routes with requirements should be denied without Authorizer. Got %d", w.Code)
+	}
+}
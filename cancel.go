@@ -0,0 +1,28 @@
+package router
+
+import (
+	"net/http"
+)
+
+// StatusClientClosedRequest is the non-standard status code commonly used to report
+// requests whose client went away before the response was sent.
+const StatusClientClosedRequest = 499
+
+// CancelHandler is called by the Dispatcher when the request context is done
+// before calling a middleware layer or the route handler. err is the context error.
+type CancelHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// guard is a Middleware that stops the request flow when the context is done,
+// calling the CancelHandler instead of the next handler.
+func (d *dispatcher) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := req.Context().Err(); err != nil {
+			if d.cancelHandler != nil {
+				d.cancelHandler(w, req, err)
+			}
+			return
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
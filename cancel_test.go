@@ -0,0 +1,85 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCancelBetweenMiddleware(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("Handler"))
+	}))
+
+	r.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte("2"))
+
+			next.ServeHTTP(res, req)
+		})
+	})
+
+	r.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte("1"))
+
+			// Client goes away
+			cancel()
+
+			next.ServeHTTP(res, req)
+		})
+	})
+
+	var cancelErr error
+	d := Build(r)
+	d.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte("0"))
+
+			next.ServeHTTP(res, req)
+		})
+	})
+	d.OnCancel(func(w http.ResponseWriter, r *http.Request, err error) {
+		cancelErr = err
+	})
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	if w.Body.String() != "01" {
+		t.Errorf("Request flow should have stopped after cancelling. Got %s", w.Body.String())
+	}
+	if cancelErr != context.Canceled {
+		t.Errorf("CancelHandler should have been called with context.Canceled. Got %v", cancelErr)
+	}
+}
+
+func TestCancelBeforeDispatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		called = true
+	}))
+
+	d := Build(r)
+	d.OnCancel(func(w http.ResponseWriter, r *http.Request, err error) {
+		w.WriteHeader(StatusClientClosedRequest)
+	})
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+
+	if called {
+		t.Error("Handler shouldn't be called for a cancelled request")
+	}
+	if w.Code != StatusClientClosedRequest {
+		t.Errorf("Response status should be 499. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,330 @@
+package router
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// CompressibleTypes are the media types compressed by default by the Compress Middleware.
+// Media types with a "+json" or "+xml" suffix are always compressible.
+var CompressibleTypes = []string{
+	"text/*",
+	"application/json",
+	"application/javascript",
+	"application/xml",
+	"application/wasm",
+	"image/svg+xml",
+}
+
+// CompressOptions configures the Compress Middleware.
+type CompressOptions struct {
+	// Level is the gzip and deflate compression level, from flate.BestSpeed to flate.BestCompression.
+	// Zero uses the default level.
+	Level int
+
+	// MinSize is the response size, in bytes, below which responses aren't compressed. Zero means 1024.
+	MinSize int
+
+	// ContentTypes lists the media types to compress, e.g. "text/*". CompressibleTypes by default.
+	ContentTypes []string
+}
+
+// Compress returns a Middleware compressing responses with gzip or deflate,
+// choosing the encoding with the highest q-value in the Accept-Encoding request header.
+//
+// Responses smaller than MinSize, with a media type not listed in ContentTypes
+// or already encoded aren't compressed. Responses are buffered up to MinSize to decide,
+// unless the handler flushes them, so streaming handlers keep working.
+// It panics if the compression level is invalid.
+func Compress(opts CompressOptions) Middleware {
+	c := &compressor{
+		level:   opts.Level,
+		minSize: opts.MinSize,
+		types:   opts.ContentTypes,
+	}
+	if c.level == 0 {
+		c.level = flate.DefaultCompression
+	}
+	if c.minSize == 0 {
+		c.minSize = 1024
+	}
+	if c.types == nil {
+		c.types = CompressibleTypes
+	}
+
+	if _, err := zlib.NewWriterLevel(io.Discard, c.level); err != nil {
+		panic("router: invalid compression level")
+	}
+	c.gzip.New = func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, c.level)
+		return w
+	}
+	// "deflate" content coding is the zlib format, not raw DEFLATE (RFC 9110 8.4.1.2)
+	c.deflate.New = func() interface{} {
+		w, _ := zlib.NewWriterLevel(io.Discard, c.level)
+		return w
+	}
+
+	return c.middleware
+}
+
+// encoder is implemented by gzip.Writer and zlib.Writer.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(io.Writer)
+}
+
+// compressor holds the Compress settings and the pooled encoders.
+type compressor struct {
+	level   int
+	minSize int
+	types   []string
+
+	gzip    sync.Pool
+	deflate sync.Pool
+}
+
+func (c *compressor) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		addVary(w.Header(), "Accept-Encoding")
+
+		encoding := acceptEncoding(req.Header.Get("Accept-Encoding"), "gzip", "deflate")
+		if encoding == "" || req.Method == http.MethodHead {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, c: c, encoding: encoding}
+		next.ServeHTTP(exposeWrapped(cw), req)
+
+		// Not deferred: on panic the buffered response is dropped so the recovery can answer
+		cw.Close()
+	})
+}
+
+// compressible reports whether responses with the Content-Type header ct can be compressed.
+func (c *compressor) compressible(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+	if strings.HasSuffix(mt, "+json") || strings.HasSuffix(mt, "+xml") {
+		return true
+	}
+
+	for _, t := range c.types {
+		if mediaTypeMatch(t, mt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// acceptEncoding returns the encoding, among the supported ones, with the highest q-value in the Accept-Encoding header,
+// preferring the first supported on ties, or an empty string if none is acceptable.
+func acceptEncoding(header string, supported ...string) string {
+	q := map[string]float64{}
+	wildcard, wildcardFound := 0.0, false
+
+	for _, av := range parseAccept(header) {
+		switch av.value {
+		case "*":
+			wildcard, wildcardFound = av.q, true
+		case "x-gzip":
+			q["gzip"] = av.q
+		default:
+			q[av.value] = av.q
+		}
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range supported {
+		v, ok := q[enc]
+		if !ok && wildcardFound {
+			v = wildcard
+		}
+		if v > bestQ {
+			best, bestQ = enc, v
+		}
+	}
+
+	return best
+}
+
+// addVary adds a value to the Vary header, unless it's already there.
+func addVary(h http.Header, value string) {
+	for _, v := range h.Values("Vary") {
+		for _, part := range strings.Split(v, ",") {
+			if p := strings.TrimSpace(part); p == "*" || strings.EqualFold(p, value) {
+				return
+			}
+		}
+	}
+
+	h.Add("Vary", value)
+}
+
+// compressWriter buffers the response until it can decide whether to compress it.
+type compressWriter struct {
+	http.ResponseWriter
+
+	c        *compressor
+	encoding string
+
+	// Status code set by the handler, sent once decided
+	status int
+
+	// Response body buffered before deciding
+	buf []byte
+
+	// Whether headers have been sent
+	started bool
+
+	// Encoder, if compressing
+	enc encoder
+}
+
+// WriteHeader implements http.ResponseWriter. The status code is sent once the compression is decided.
+func (w *compressWriter) WriteHeader(code int) {
+	if w.started || w.status != 0 {
+		return
+	}
+
+	// Informational responses go straight through
+	if code >= 100 && code < 200 && code != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	w.status = code
+}
+
+// Write implements http.ResponseWriter
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.started {
+		if w.enc != nil {
+			return w.enc.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
+	w.buf = append(w.buf, b...)
+	if len(w.buf) < w.c.minSize {
+		return len(b), nil
+	}
+
+	if err := w.start(true); err != nil {
+		return 0, err
+	}
+
+	return len(b), nil
+}
+
+// start decides whether to compress the response, sends the headers and the buffered body.
+// large tells if the response is large enough, or streamed, to be worth compressing.
+func (w *compressWriter) start(large bool) error {
+	w.started = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	h := w.Header()
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	if large && w.bodyAllowed() && h.Get("Content-Encoding") == "" && w.c.compressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.encoding)
+		if etag := h.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			// The compressed representation isn't byte-for-byte equal
+			h.Set("ETag", "W/"+etag)
+		}
+
+		if w.encoding == "gzip" {
+			w.enc = w.c.gzip.Get().(encoder)
+		} else {
+			w.enc = w.c.deflate.Get().(encoder)
+		}
+		w.enc.Reset(w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+// bodyAllowed reports whether the response status can have a compressible body.
+func (w *compressWriter) bodyAllowed() bool {
+	return w.status != http.StatusNoContent &&
+		w.status != http.StatusNotModified &&
+		w.status != http.StatusPartialContent &&
+		w.status >= http.StatusOK
+}
+
+// Close sends the response if it's still buffered and finishes the compressed stream.
+func (w *compressWriter) Close() error {
+	if !w.started {
+		if err := w.start(len(w.buf) >= w.c.minSize); err != nil {
+			return err
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	if w.encoding == "gzip" {
+		w.c.gzip.Put(w.enc)
+	} else {
+		w.c.deflate.Put(w.enc)
+	}
+	w.enc = nil
+
+	return err
+}
+
+// Flush implements http.Flusher. Flushed responses are compressed regardless of their size.
+func (w *compressWriter) Flush() {
+	if !w.started {
+		w.start(true)
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// hijacked implements wrappedWriter: the buffered response is dropped.
+func (w *compressWriter) hijacked() {
+	w.started = true
+	w.buf = nil
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
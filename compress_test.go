@@ -0,0 +1,150 @@
+package router
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var largeText = strings.Repeat("Lorem ipsum dolor sit amet. ", 100)
+
+func TestAcceptEncoding(t *testing.T) {
+	for header, expected := range map[string]string{
+		"":                         "",
+		"gzip":                     "gzip",
+		"deflate":                  "deflate",
+		"gzip, deflate, br":        "gzip",
+		"deflate, gzip;q=0.5":      "deflate",
+		"gzip;q=0, deflate;q=0.1":  "deflate",
+		"br":                       "",
+		"*":                        "gzip",
+		"*;q=0.5, gzip;q=0":        "deflate",
+		"identity":                 "",
+		"x-gzip":                   "gzip",
+		"gzip;q=0, deflate;q=0, *": "",
+	} {
+		if enc := acceptEncoding(header, "gzip", "deflate"); enc != expected {
+			t.Errorf("Accept-Encoding '%s' should negotiate '%s'. Got '%s'", header, expected, enc)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	r := New("/")
+	r.Add("/text", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(largeText[:500]))
+		w.Write([]byte(largeText[500:]))
+	}))
+	r.Add("/small", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("small"))
+	}))
+	r.Add("/image", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte(largeText))
+	}))
+	r.Add("/encoded", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte(largeText))
+	}))
+	r.Wrap(Compress(CompressOptions{}))
+
+	d := Build(r)
+
+	for _, c := range []struct {
+		path, accept, encoding string
+	}{
+		{"/text", "gzip, deflate", "gzip"},
+		{"/text", "deflate", "deflate"},
+		{"/text", "", ""},
+		{"/small", "gzip", ""},
+		{"/image", "gzip", ""},
+		{"/encoded", "gzip", "br"},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		req.Header.Set("Accept-Encoding", c.accept)
+		d.ServeHTTP(w, req)
+
+		if w.Header().Get("Content-Encoding") != c.encoding {
+			t.Errorf("%s with '%s' should be encoded with '%s'. Got '%s'", c.path, c.accept, c.encoding, w.Header().Get("Content-Encoding"))
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%s should vary on Accept-Encoding. Got '%s'", c.path, w.Header().Get("Vary"))
+		}
+
+		var body io.Reader = w.Body
+		switch c.encoding {
+		case "gzip":
+			body, _ = gzip.NewReader(w.Body)
+		case "deflate":
+			var err error
+			if body, err = zlib.NewReader(w.Body); err != nil {
+				t.Fatalf("Deflate body should use the zlib format. Got %s", err)
+			}
+		}
+		b, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if c.path == "/text" {
+			if string(b) != largeText {
+				t.Errorf("%s with '%s' body isn't as expected", c.path, c.accept)
+			}
+			if c.encoding != "" && w.Header().Get("ETag") != `W/"v1"` {
+				t.Errorf("Compressed ETag should be weak. Got %s", w.Header().Get("ETag"))
+			}
+		}
+		if c.path == "/small" && string(b) != "small" {
+			t.Errorf("Small body isn't as expected. Got %s", string(b))
+		}
+	}
+}
+
+func TestCompressFlush(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	r := New("/")
+	r.Add("/events", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/event-stream")
+		rw.Write([]byte("data: 1\n\n"))
+		rw.(http.Flusher).Flush()
+
+		// The first event must be sent before the response ends
+		if !w.Flushed || w.Body.Len() == 0 {
+			t.Error("Flush should send the buffered response")
+		}
+
+		rw.Write([]byte("data: 2\n\n"))
+	}))
+	r.Wrap(Compress(CompressOptions{}))
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	Build(r).ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Flushed stream should be compressed. Got '%s'", w.Header().Get("Content-Encoding"))
+	}
+
+	gz, _ := gzip.NewReader(w.Body)
+	b, _ := io.ReadAll(gz)
+	if string(b) != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("Stream body isn't as expected. Got %s", string(b))
+	}
+}
+
+func TestCompressHijacker(t *testing.T) {
+	testHijacker(t, Compress(CompressOptions{}), func() *http.Request {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		return req
+	})
+}
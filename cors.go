@@ -0,0 +1,182 @@
+package router
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the CORS Middleware.
+type CORSOptions struct {
+	// AllowedOrigins lists the origins allowed, compared case-insensitively.
+	// "*" allows any origin and a single "*" inside an origin works as a wildcard,
+	// e.g. "https://*.example.com".
+	AllowedOrigins []string
+
+	// AllowedOriginPatterns allows origins matching any of the regular expressions.
+	AllowedOriginPatterns []*regexp.Regexp
+
+	// AllowOriginFunc allows origins for which it returns true.
+	AllowOriginFunc func(origin string) bool
+
+	// AllowedHeaders lists the request headers allowed on preflight requests. "*" allows any header.
+	AllowedHeaders []string
+
+	// ExposedHeaders lists the response headers exposed to the client.
+	ExposedHeaders []string
+
+	// AllowCredentials allows requests with credentials (cookies, authorization headers).
+	AllowCredentials bool
+
+	// MaxAge is how long preflight responses can be cached. Zero omits the header.
+	MaxAge time.Duration
+}
+
+// CORS returns a Middleware implementing Cross-Origin Resource Sharing.
+//
+// Preflight OPTIONS requests are answered with the methods actually registered for the matched path,
+// so routes restricted with Route.Methods don't need an OPTIONS handler nor a hard-coded methods list.
+func CORS(opts CORSOptions) Middleware {
+	c := &cors{
+		opts:    opts,
+		headers: make([]string, 0, len(opts.AllowedHeaders)),
+	}
+
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			c.anyOrigin = true
+		}
+	}
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			c.anyHeader = true
+		}
+		c.headers = append(c.headers, http.CanonicalHeaderKey(h))
+	}
+
+	return c.middleware
+}
+
+// cors holds the parsed CORSOptions.
+type cors struct {
+	opts      CORSOptions
+	anyOrigin bool
+	anyHeader bool
+	headers   []string
+}
+
+func (c *cors) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Origin")
+
+		origin := req.Header.Get("Origin")
+		if origin == "" || !c.allowOrigin(origin) {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		// Preflight
+		if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, req, origin)
+			return
+		}
+
+		c.setOrigin(w, origin)
+		if len(c.opts.ExposedHeaders) > 0 {
+			w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.opts.ExposedHeaders, ", "))
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// preflight answers a preflight request.
+// CORS headers are only set if the method and headers requested are allowed.
+func (c *cors) preflight(w http.ResponseWriter, req *http.Request, origin string) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+	defer w.WriteHeader(http.StatusNoContent)
+
+	method := strings.ToUpper(req.Header.Get("Access-Control-Request-Method"))
+	methods := pathMethods(req)
+	if methods == nil {
+		methods = []string{method}
+	} else if !contains(methods, method) {
+		return
+	}
+
+	headers := make([]string, 0)
+	for _, h := range strings.Split(req.Header.Get("Access-Control-Request-Headers"), ",") {
+		h = http.CanonicalHeaderKey(strings.TrimSpace(h))
+		if h == "" {
+			continue
+		}
+		if !c.anyHeader && !contains(c.headers, h) {
+			return
+		}
+		headers = append(headers, h)
+	}
+
+	c.setOrigin(w, origin)
+	w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(headers) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	}
+	if c.opts.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.opts.MaxAge.Seconds())))
+	}
+}
+
+// setOrigin sets the allowed origin and credentials headers.
+// "*" is only used for any origin without credentials, as browsers reject it otherwise.
+func (c *cors) setOrigin(w http.ResponseWriter, origin string) {
+	if c.anyOrigin && !c.opts.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	if c.opts.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// allowOrigin checks the origin against the exact and wildcard origins, patterns and func.
+func (c *cors) allowOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+
+	lower := strings.ToLower(origin)
+	for _, o := range c.opts.AllowedOrigins {
+		o = strings.ToLower(o)
+		if i := strings.IndexByte(o, '*'); i >= 0 {
+			if len(lower) > len(o)-1 && strings.HasPrefix(lower, o[:i]) && strings.HasSuffix(lower, o[i+1:]) {
+				return true
+			}
+		} else if o == lower {
+			return true
+		}
+	}
+
+	for _, p := range c.opts.AllowedOriginPatterns {
+		if p.MatchString(origin) {
+			return true
+		}
+	}
+
+	return c.opts.AllowOriginFunc != nil && c.opts.AllowOriginFunc(origin)
+}
+
+// pathMethods returns the methods registered for the path of the route matched for the request.
+// It's nil when any method is allowed or no route was matched.
+func pathMethods(req *http.Request) []string {
+	rt := matchedRoute(req)
+	if rt == nil {
+		return nil
+	}
+
+	return rt.node.methods(req)
+}
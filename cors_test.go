@@ -0,0 +1,134 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func corsDispatcher(opts CORSOptions) Dispatcher {
+	r := New("/")
+	r.Add("/users", http.HandlerFunc(handler)).Methods("GET", "POST")
+	r.Add("/users", http.HandlerFunc(handler)).Methods("DELETE")
+	r.Add("/any", http.HandlerFunc(handler))
+
+	d := Build(r)
+	d.Wrap(CORS(opts))
+
+	return d
+}
+
+func TestCORSPreflight(t *testing.T) {
+	d := corsDispatcher(CORSOptions{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedHeaders:   []string{"Content-Type", "X-Token"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("OPTIONS", "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type, x-token")
+	d.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Preflight should respond 204. Got %d", w.Code)
+	}
+	for k, v := range map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, POST, DELETE",
+		"Access-Control-Allow-Headers":     "Content-Type, X-Token",
+		"Access-Control-Max-Age":           "600",
+	} {
+		if w.Header().Get(k) != v {
+			t.Errorf("%s header should be '%s'. Got '%s'", k, v, w.Header().Get(k))
+		}
+	}
+	if vary := w.Header().Values("Vary"); len(vary) == 0 || vary[0] != "Origin" {
+		t.Errorf("Vary header should include Origin. Got %v", vary)
+	}
+
+	// Method not registered for the path
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("OPTIONS", "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PUT")
+	d.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Preflight for a method not registered shouldn't be allowed")
+	}
+
+	// Header not allowed
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("OPTIONS", "/users", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	req.Header.Set("Access-Control-Request-Headers", "X-Other")
+	d.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("Preflight with headers not allowed shouldn't be allowed")
+	}
+
+	// Routes without methods allow the requested one
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("OPTIONS", "/any", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "PATCH")
+	d.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Methods") != "PATCH" {
+		t.Errorf("Access-Control-Allow-Methods should be 'PATCH'. Got '%s'", w.Header().Get("Access-Control-Allow-Methods"))
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	d := corsDispatcher(CORSOptions{
+		AllowedOrigins: []string{"*"},
+		ExposedHeaders: []string{"X-Total"},
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Origin", "https://other.com")
+	d.ServeHTTP(w, req)
+
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Access-Control-Allow-Origin should be '*'. Got '%s'", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w.Header().Get("Access-Control-Expose-Headers") != "X-Total" {
+		t.Errorf("Access-Control-Expose-Headers should be 'X-Total'. Got '%s'", w.Header().Get("Access-Control-Expose-Headers"))
+	}
+	if w.Body.String() != "Hello test!" {
+		t.Errorf("Handler should have been called. Got %s", w.Body.String())
+	}
+}
+
+func TestCORSOrigins(t *testing.T) {
+	c := &cors{opts: CORSOptions{
+		AllowedOrigins:        []string{"https://app.example.com", "https://*.example.org"},
+		AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)},
+		AllowOriginFunc: func(origin string) bool {
+			return origin == "https://partner.com"
+		},
+	}}
+
+	for origin, allowed := range map[string]bool{
+		"https://app.example.com":   true,
+		"HTTPS://APP.EXAMPLE.COM":   true,
+		"https://a.b.example.org":   true,
+		"https://example.org":       false,
+		"http://localhost:3000":     true,
+		"http://localhost":          false,
+		"https://partner.com":       true,
+		"https://evil.com":          false,
+		"https://app.example.com.x": false,
+	} {
+		if c.allowOrigin(origin) != allowed {
+			t.Errorf("Origin %s allowed should be %v", origin, allowed)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package debug provides a router exposing diagnostics for a router Dispatcher.
+//
+// It imports net/http/pprof and expvar, which register their handlers in http.DefaultServeMux,
+// so it's kept apart from the router package and only affects the programs importing it.
+package debug
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/pprof"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/leonelquinteros/router"
+)
+
+// New returns a Router exposing diagnostics under prefix:
+//
+//	<prefix>/pprof/   net/http/pprof profiles index, with each profile under it
+//	<prefix>/vars     expvar variables
+//	<prefix>/routes   route table of the Dispatcher serving the request, as text or JSON
+//
+// The protect Middleware, if any, wrap all the routes, e.g. to require authentication.
+// Importing this package also registers the pprof and expvar handlers in http.DefaultServeMux,
+// as the standard library does, so it shouldn't be served publicly either.
+func New(prefix string, protect ...router.Middleware) router.Router {
+	r := router.New(prefix)
+
+	r.Add("/pprof", http.HandlerFunc(pprofIndex)).Methods(http.MethodGet)
+	r.Add("/pprof/cmdline", http.HandlerFunc(pprof.Cmdline)).Methods(http.MethodGet)
+	r.Add("/pprof/profile", http.HandlerFunc(pprof.Profile)).Methods(http.MethodGet)
+	r.Add("/pprof/symbol", http.HandlerFunc(pprof.Symbol)).Methods(http.MethodGet, http.MethodPost)
+	r.Add("/pprof/trace", http.HandlerFunc(pprof.Trace)).Methods(http.MethodGet)
+	r.Add("/pprof/:profile", http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pprof.Handler(router.Param(req, "profile")).ServeHTTP(w, req)
+	})).Methods(http.MethodGet)
+
+	r.Add("/vars", expvar.Handler()).Methods(http.MethodGet)
+	r.Add("/routes", http.HandlerFunc(routeTable)).Methods(http.MethodGet)
+
+	for _, m := range protect {
+		r.Wrap(m)
+	}
+
+	return r
+}
+
+// pprofIndex serves the pprof index, redirecting to the path with a trailing slash so its relative links work.
+func pprofIndex(w http.ResponseWriter, req *http.Request) {
+	if !strings.HasSuffix(strings.SplitN(req.RequestURI, "?", 2)[0], "/") {
+		http.Redirect(w, req, path.Base(req.URL.Path)+"/", http.StatusMovedPermanently)
+		return
+	}
+
+	pprof.Index(w, req)
+}
+
+// routeTable writes the routes of the Dispatcher serving the request.
+// It responds with JSON if the client accepts it and plain text otherwise.
+func routeTable(w http.ResponseWriter, req *http.Request) {
+	d := router.DispatcherFromContext(req.Context())
+	if d == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	routes := d.Routes()
+
+	if acceptsJSON(req.Header.Get("Accept")) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(routes)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHODS\tPATTERN\tNAME\tREQUIRES")
+	for _, rt := range routes {
+		methods := "*"
+		if len(rt.Methods) > 0 {
+			methods = strings.Join(rt.Methods, ",")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", methods, rt.Pattern, rt.Name, strings.Join(rt.Requires, ","))
+	}
+	tw.Flush()
+
+	if unreachable := d.Unreachable(); len(unreachable) > 0 {
+		fmt.Fprintln(w, "\nUNREACHABLE")
+		for _, u := range unreachable {
+			fmt.Fprintln(w, u)
+		}
+	}
+}
+
+// acceptsJSON reports whether the Accept header explicitly lists application/json with a positive q-value.
+func acceptsJSON(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mt != "application/json" {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(q, 64); err == nil && f <= 0 {
+				continue
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
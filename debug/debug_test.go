@@ -0,0 +1,96 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/leonelquinteros/router"
+)
+
+func handler(w http.ResponseWriter, r *http.Request) {}
+
+func TestDebug(t *testing.T) {
+	api := router.New("/api")
+	api.Add("/users/:id", http.HandlerFunc(handler)).Methods("GET").Name("user").Require("users:read")
+	api.Add("/users/:id", http.HandlerFunc(handler)).Methods("GET")
+
+	d := router.Build(api, New("/debug"))
+
+	for _, c := range []struct {
+		path, accept string
+		code         int
+		contains     string
+	}{
+		{"/debug/pprof/", "", http.StatusOK, "goroutine"},
+		{"/debug/pprof", "", http.StatusMovedPermanently, ""},
+		{"/debug/pprof/goroutine?debug=1", "", http.StatusOK, "goroutine profile"},
+		{"/debug/pprof/cmdline", "", http.StatusOK, ""},
+		{"/debug/vars", "", http.StatusOK, "memstats"},
+		{"/debug/routes", "", http.StatusOK, "/api/users/:id         user  users:read"},
+		{"/debug/routes", "*/*", http.StatusOK, "/api/users/:id is shadowed by /api/users/:id"},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		req.Header.Set("Accept", c.accept)
+		d.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("%s should respond %d. Got %d", c.path, c.code, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), c.contains) {
+			t.Errorf("%s body should contain '%s'. Got %s", c.path, c.contains, w.Body.String())
+		}
+	}
+
+	// JSON route table
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/debug/routes", nil)
+	req.Header.Set("Accept", "application/json")
+	d.ServeHTTP(w, req)
+
+	var routes []router.RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	if len(routes) == 0 || routes[0].Pattern != "/api/users/:id" || routes[0].Name != "user" {
+		t.Errorf("Route table should start with the named user route. Got %+v", routes)
+	}
+
+	found := make(map[string]bool)
+	for _, rt := range routes {
+		found[strings.Join(rt.Methods, ",")+" "+rt.Pattern] = true
+	}
+	for _, want := range []string{
+		"GET /api/users/:id",
+		"GET /debug/pprof",
+		"GET /debug/pprof/:profile",
+		"GET,POST /debug/pprof/symbol",
+		"GET /debug/vars",
+		"GET /debug/routes",
+	} {
+		if !found[want] {
+			t.Errorf("Route table should list %s. Got %+v", want, routes)
+		}
+	}
+}
+
+func TestDebugProtect(t *testing.T) {
+	d := router.Build(New("/debug", router.BasicAuth("Debug", map[string]string{"admin": "pass"})))
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/debug/vars", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Debug routes should be protected. Got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/debug/vars", nil)
+	req.SetBasicAuth("admin", "pass")
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Authenticated request should be served. Got %d", w.Code)
+	}
+}
@@ -1,7 +1,12 @@
 package router
 
 import (
+	"context"
 	"net/http"
+	"regexp"
+	"sort"
+	"sync"
+	"sync/atomic"
 )
 
 // Dispatcher is constructed by Route() and works as a replacement
@@ -15,14 +20,74 @@ type Dispatcher interface {
 
 	// Wrap takes a Middleware to wrap all handlers in order (from inside out) at dispatcher level.
 	Wrap(Middleware)
+
+	// Versioning configures API version resolution.
+	// vendor is the name used on vendor media types (e.g. "acme" for "application/vnd.acme.v2+json")
+	// and fallback is the version used when the request doesn't declare one.
+	Versioning(vendor, fallback string)
+
+	// Version adds Routers serving an API version, e.g. "2" or "v2".
+	// The version is resolved from the first path part ("/v2/users", removed before matching)
+	// or from the Accept header vendor media type. Versioned routers are matched before the rest.
+	Version(version string, routes ...Router) *Version
+
+	// Routes lists the routes of the routers, including the versioned ones, router by router.
+	Routes() []*RouteInfo
+
+	// Unreachable lists the routes that can never be matched
+	// because a higher ranked route takes all their requests, e.g. a catch-all in a router with higher priority.
+	Unreachable() []string
+
+	// Recover sets the PanicHandler called when a handler panics, after a 500 response status has been set.
+	// By default panics are logged with the standard logger. If stack is true, stack traces are captured.
+	Recover(h PanicHandler, stack bool)
+
+	// Trace sets the Tracer receiving the routing and handler spans.
+	Trace(Tracer)
+
+	// TimeoutHandler sets the handler answering requests whose route timeout expired
+	// before the response was started. By default it responds 503 Service Unavailable.
+	TimeoutHandler(http.Handler)
+
+	// OnCancel sets the CancelHandler called when the request flow stops because its context is done,
+	// e.g. to log a 499 status for clients that went away. By default the flow just stops.
+	OnCancel(CancelHandler)
+
+	// Authorize sets the Authorizer enforcing the requirements of routes configured with Route.Require.
+	// Without Authorizer, requests to routes with requirements are denied.
+	Authorize(Authorizer)
+
+	// Drain stops accepting requests, answering new ones with 503 Service Unavailable, "Retry-After"
+	// and "Connection: close" headers, and waits for the in-flight ones to finish.
+	// It returns the context error if the context is done first. It's meant to be called before http.Server.Shutdown.
+	Drain(ctx context.Context) error
+
+	// DrainExempt adds paths still served while draining, besides "/livez", "/readyz" and "/healthz",
+	// e.g. the health routes registered under a prefix, so probes keep getting their real answer
+	// and readiness fails through Health.Watch instead of the generic 503.
+	DrainExempt(paths ...string)
+
+	// Draining reports whether Drain has been called.
+	Draining() bool
+
+	// InFlight returns the number of requests being served.
+	// Requests whose route timeout expired are counted until their handler returns.
+	InFlight() int64
+
+	// InFlightRoutes returns the number of requests being served by route pattern, for the routes serving any.
+	InFlightRoutes() map[string]int64
 }
 
 // Build constructs a Dispatcher that implements http.Handler and will contain
 // all routes defined in the Router objects passed as parameters.
 func Build(routes ...Router) Dispatcher {
 	d := &dispatcher{
-		routes:     make([]Router, len(routes)),
-		middleware: make([]Middleware, 0),
+		routes:         make([]Router, len(routes)),
+		middleware:     make([]Middleware, 0),
+		panicHandler:   logPanic,
+		timeoutHandler: http.HandlerFunc(serviceUnavailable),
+		routeInFlight:  make(map[string]int64),
+		drainExempt:    map[string]bool{"/livez": true, "/readyz": true, "/healthz": true},
 	}
 
 	for i, r := range routes {
@@ -32,35 +97,132 @@ func Build(routes ...Router) Dispatcher {
 	return d
 }
 
+type dispatcherKey struct{}
+
+// setDispatcher stores the Dispatcher serving the request in its context.
+func setDispatcher(req *http.Request, d Dispatcher) {
+	*req = *req.WithContext(context.WithValue(req.Context(), dispatcherKey{}, d))
+}
+
+// DispatcherFromContext returns the Dispatcher serving the request the context belongs to,
+// e.g. to list its routes. It's nil for routers used on their own.
+func DispatcherFromContext(ctx context.Context) Dispatcher {
+	d, _ := ctx.Value(dispatcherKey{}).(Dispatcher)
+
+	return d
+}
+
 type dispatcher struct {
 	routes     []Router
 	middleware []Middleware
+	versioning versioning
+
+	// Panic recovery
+	panicHandler PanicHandler
+	stack        bool
+
+	// Tracing
+	tracer Tracer
+
+	// Route timeouts response
+	timeoutHandler http.Handler
+
+	// Context cancellation hook
+	cancelHandler CancelHandler
+
+	// Route requirements enforcement
+	authorizer Authorizer
+
+	// Lifecycle
+	draining      atomic.Bool
+	inFlight      atomic.Int64
+	mu            sync.Mutex
+	routeInFlight map[string]int64
+
+	// Paths served while draining
+	drainExempt map[string]bool
 }
 
 // ServeHTTP implements http.Handler interface.
 // Takes care of middleware execution and stops the request flow if at any point the Context is cancelled.
-func (d *dispatcher) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+// Handler panics are recovered and passed to the PanicHandler.
+// While draining, requests are rejected with 503.
+func (d *dispatcher) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	done, ok := d.track(req)
+	if !ok {
+		rejectDraining(rw, req)
+		return
+	}
+	defer done()
+
+	w := wrapWriter(rw)
+	defer d.recovery(w, req)
+
+	// Trace
+	var span, routing Span
+	if d.tracer != nil {
+		span = d.startSpan(req)
+		defer endSpan(span, w)
+
+		_, routing = d.tracer.Start(req.Context(), "route")
+	}
+
 	// Match
-	for _, r := range d.routes {
-		// Found
-		if h := r.Match(req); h != nil {
-			// Add middleware
-			for _, m := range d.middleware {
-				h = m(h)
-			}
-
-			// Dispatch
-			h.ServeHTTP(w, req)
-
-			// Return at route match
-			return
+	setDispatcher(req, d)
+	if d.authorizer != nil {
+		setAuthorizer(req, d.authorizer)
+	}
+	h := d.match(w, req)
+	if routing != nil {
+		routing.End()
+	}
+
+	if h != nil {
+		if span != nil {
+			nameSpan(span, req)
 		}
+
+		defer d.trackRoute(Pattern(req))()
+
+		// Add middleware
+		for _, m := range d.middleware {
+			h = d.guard(m(h))
+		}
+
+		// Dispatch
+		h.ServeHTTP(w.writer(), req)
+
+		// Return at route match
+		return
 	}
 
 	// 404 Not Found
 	return
 }
 
+// match looks for the handler of the best router matching the request, trying versioned routers first.
+func (d *dispatcher) match(w http.ResponseWriter, req *http.Request) http.Handler {
+	if v, fromPath := d.versioning.resolve(req); v != nil {
+		path := req.URL.Path
+		rawPath := req.URL.RawPath
+		if fromPath {
+			stripVersion(req)
+		}
+
+		if h := matchRouters(v.routes, req, d.guard); h != nil {
+			setVersion(req, v)
+			v.setHeaders(w)
+			return h
+		}
+
+		// Restore path for unversioned routers
+		req.URL.Path = path
+		req.URL.RawPath = rawPath
+	}
+
+	return matchRouters(d.routes, req, d.guard)
+}
+
 func (d *dispatcher) Add(r Router) {
 	d.routes = append(d.routes, r)
 }
@@ -68,3 +230,81 @@ func (d *dispatcher) Add(r Router) {
 func (d *dispatcher) Wrap(m Middleware) {
 	d.middleware = append(d.middleware, m)
 }
+
+func (d *dispatcher) Versioning(vendor, fallback string) {
+	d.versioning.fallback = versionName(fallback)
+	d.versioning.mediaType = regexp.MustCompile(`^application/vnd\.` + regexp.QuoteMeta(vendor) + `\.v([^+]+)(\+.+)?$`)
+}
+
+func (d *dispatcher) Version(version string, routes ...Router) *Version {
+	name := versionName(version)
+	if d.versioning.versions == nil {
+		d.versioning.versions = make(map[string]*Version)
+	}
+
+	v, ok := d.versioning.versions[name]
+	if !ok {
+		v = &Version{name: name}
+		d.versioning.versions[name] = v
+	}
+	v.routes = append(v.routes, routes...)
+
+	return v
+}
+
+func (d *dispatcher) Routes() []*RouteInfo {
+	routes := append([]Router{}, d.routes...)
+	for _, name := range d.versionNames() {
+		routes = append(routes, d.versioning.versions[name].routes...)
+	}
+
+	infos := make([]*RouteInfo, 0)
+	for _, r := range routes {
+		for _, rt := range r.impl().tree.allRoutes() {
+			infos = append(infos, rt.info())
+		}
+	}
+
+	return infos
+}
+
+// versionNames returns the names of the API versions, sorted.
+func (d *dispatcher) versionNames() []string {
+	names := make([]string, 0, len(d.versioning.versions))
+	for name := range d.versioning.versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+func (d *dispatcher) Unreachable() []string {
+	found := unreachable(d.routes)
+	for _, name := range d.versionNames() {
+		found = append(found, unreachable(d.versioning.versions[name].routes)...)
+	}
+
+	return found
+}
+
+func (d *dispatcher) Recover(h PanicHandler, stack bool) {
+	d.panicHandler = h
+	d.stack = stack
+}
+
+func (d *dispatcher) Trace(t Tracer) {
+	d.tracer = t
+}
+
+func (d *dispatcher) TimeoutHandler(h http.Handler) {
+	d.timeoutHandler = h
+}
+
+func (d *dispatcher) OnCancel(h CancelHandler) {
+	d.cancelHandler = h
+}
+
+func (d *dispatcher) Authorize(a Authorizer) {
+	d.authorizer = a
+}
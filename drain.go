@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"time"
+)
+
+// drainPoll is how often Drain checks for in-flight requests.
+const drainPoll = 10 * time.Millisecond
+
+// track counts the request as in flight until the returned function is called.
+// It returns false, without counting it, if the Dispatcher is draining and the request path isn't exempt.
+func (d *dispatcher) track(req *http.Request) (func(), bool) {
+	// Count before checking, so Drain can't miss a request that passed the check
+	d.inFlight.Add(1)
+	if d.draining.Load() && !d.drainExempt[path.Clean("/"+req.URL.Path)] {
+		d.inFlight.Add(-1)
+		return nil, false
+	}
+
+	return func() { d.inFlight.Add(-1) }, true
+}
+
+// trackRoute counts the request as in flight for the route pattern until the returned function is called.
+func (d *dispatcher) trackRoute(pattern string) func() {
+	d.mu.Lock()
+	d.routeInFlight[pattern]++
+	d.mu.Unlock()
+
+	return func() {
+		d.mu.Lock()
+		if d.routeInFlight[pattern]--; d.routeInFlight[pattern] == 0 {
+			delete(d.routeInFlight, pattern)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// hold counts a request as in flight, in total and for its route pattern, until the returned function is called.
+// It keeps handlers whose route timeout expired counted after the request returned. It's a no-op without Dispatcher.
+func (d *dispatcher) hold(pattern string) func() {
+	if d == nil {
+		return func() {}
+	}
+
+	d.inFlight.Add(1)
+	release := d.trackRoute(pattern)
+
+	return func() {
+		release()
+		d.inFlight.Add(-1)
+	}
+}
+
+// rejectDraining answers requests received while draining with 503 Service Unavailable,
+// asking the client to retry, on a new connection, against another instance.
+func rejectDraining(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Retry-After", "1")
+	w.Header().Set("Connection", "close")
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+func (d *dispatcher) Drain(ctx context.Context) error {
+	d.draining.Store(true)
+
+	t := time.NewTicker(drainPoll)
+	defer t.Stop()
+
+	for d.inFlight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+
+	return nil
+}
+
+func (d *dispatcher) DrainExempt(paths ...string) {
+	for _, p := range paths {
+		d.drainExempt[path.Clean("/"+p)] = true
+	}
+}
+
+func (d *dispatcher) Draining() bool {
+	return d.draining.Load()
+}
+
+func (d *dispatcher) InFlight() int64 {
+	return d.inFlight.Load()
+}
+
+func (d *dispatcher) InFlightRoutes() map[string]int64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	routes := make(map[string]int64, len(d.routeInFlight))
+	for pattern, n := range d.routeInFlight {
+		routes[pattern] = n
+	}
+
+	return routes
+}
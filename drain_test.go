@@ -0,0 +1,176 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	r := New("/")
+	r.Add("/slow/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	}))
+	r.Add("/fast", http.HandlerFunc(handler))
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	go d.ServeHTTP(w, httptest.NewRequest("GET", "/slow/1", nil))
+	<-started
+
+	if d.InFlight() != 1 {
+		t.Errorf("1 request should be in flight. Got %d", d.InFlight())
+	}
+	if routes := d.InFlightRoutes(); len(routes) != 1 || routes["/slow/:id"] != 1 {
+		t.Errorf("1 request should be in flight for /slow/:id. Got %v", routes)
+	}
+
+	// Drain times out while the request is in flight
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := d.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Drain should time out. Got %v", err)
+	}
+	if !d.Draining() {
+		t.Error("Dispatcher should be draining")
+	}
+
+	// New requests are rejected
+	rejected := httptest.NewRecorder()
+	d.ServeHTTP(rejected, httptest.NewRequest("GET", "/fast", nil))
+	if rejected.Code != http.StatusServiceUnavailable {
+		t.Errorf("Requests should be rejected while draining. Got %d", rejected.Code)
+	}
+	if rejected.Header().Get("Retry-After") == "" || rejected.Header().Get("Connection") != "close" {
+		t.Errorf("Rejected requests should get Retry-After and Connection headers. Got %v", rejected.Header())
+	}
+
+	// Drain finishes once the request is done
+	drained := make(chan error)
+	go func() {
+		drained <- d.Drain(context.Background())
+	}()
+	close(release)
+
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Errorf("Drain should finish. Got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Drain should finish when in-flight requests are done")
+	}
+
+	if d.InFlight() != 0 || len(d.InFlightRoutes()) != 0 {
+		t.Errorf("No request should be in flight. Got %d %v", d.InFlight(), d.InFlightRoutes())
+	}
+	if w.Body.String() != "done" {
+		t.Errorf("In-flight request should be served. Got %s", w.Body.String())
+	}
+}
+
+func TestHealthWatch(t *testing.T) {
+	ops := New("/")
+	h := NewHealth(0)
+	h.Register(ops)
+
+	api := Build(New("/"))
+	h.Watch(api)
+
+	d := Build(ops)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Service should be ready. Got %d", w.Code)
+	}
+
+	api.Drain(context.Background())
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Service shouldn't be ready while the dispatcher drains. Got %d", w.Code)
+	}
+}
+
+func TestDrainExempt(t *testing.T) {
+	r := New("/")
+	h := NewHealth(0)
+	h.Register(r)
+	r.Add("/ops/ping", http.HandlerFunc(handler))
+	r.Add("/users", http.HandlerFunc(handler))
+
+	d := Build(r)
+	h.Watch(d)
+	d.DrainExempt("/ops/ping")
+	d.Drain(context.Background())
+
+	for _, c := range []struct {
+		path    string
+		code    int
+		generic bool
+	}{
+		{"/livez", http.StatusOK, false},
+		{"/readyz", http.StatusServiceUnavailable, false},
+		{"/ops/ping", http.StatusOK, false},
+		{"/users", http.StatusServiceUnavailable, true},
+	} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+
+		if w.Code != c.code {
+			t.Errorf("%s should respond %d while draining. Got %d", c.path, c.code, w.Code)
+		}
+		if rejected := w.Header().Get("Retry-After") != ""; rejected != c.generic {
+			t.Errorf("%s rejected while draining should be %t. Got %t", c.path, c.generic, rejected)
+		}
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	release := make(chan struct{})
+	returned := make(chan struct{})
+
+	r := New("/")
+	r.Add("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignores its context
+		<-release
+		close(returned)
+	})).Timeout(10 * time.Millisecond)
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Request should time out. Got %d", w.Code)
+	}
+
+	if d.InFlight() != 1 || d.InFlightRoutes()["/slow"] != 1 {
+		t.Errorf("Timed out handler should be in flight until it returns. Got %d %v", d.InFlight(), d.InFlightRoutes())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Drain should wait for the timed out handler. Got %v", err)
+	}
+
+	close(release)
+	<-returned
+	if err := d.Drain(context.Background()); err != nil {
+		t.Errorf("Drain should finish once the handler returns. Got %v", err)
+	}
+	if d.InFlight() != 0 || len(d.InFlightRoutes()) != 0 {
+		t.Errorf("No request should be in flight. Got %d %v", d.InFlight(), d.InFlightRoutes())
+	}
+}
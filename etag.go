@@ -0,0 +1,253 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ETagOptions configures the ETag Middleware.
+type ETagOptions struct {
+	// MaxSize is the largest response body, in bytes, buffered to compute its ETag.
+	// Larger responses are streamed without ETag. Zero means 1 MB.
+	MaxSize int
+
+	// Weak generates weak ETags, for responses that are semantically but not byte-for-byte equivalent.
+	Weak bool
+
+	// Current returns the current ETag of the resource for unsafe requests with an If-Match header,
+	// or "" if the resource doesn't exist. It's required to accept those requests:
+	// without it, they are answered with 412 Precondition Failed.
+	Current func(*http.Request) string
+}
+
+// ETag returns a Middleware adding ETags to GET and HEAD responses and handling conditional requests.
+//
+// Successful responses up to MaxSize are buffered to compute their ETag, unless the handler sets one.
+// HEAD requests are served as GET, without sending the body, so their ETag is the GET one.
+// Requests with a matching If-None-Match header, or an If-Modified-Since header not older than
+// the Last-Modified response header, are answered with 304 Not Modified.
+// Unsafe requests with an If-Match header not matching the current ETag are answered with
+// 412 Precondition Failed, without calling the handler. Their current ETag is provided by ETagOptions.Current.
+func ETag(opts ETagOptions) Middleware {
+	if opts.MaxSize == 0 {
+		opts.MaxSize = 1 << 20
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch req.Method {
+			case http.MethodGet, http.MethodHead:
+				ew := &etagWriter{ResponseWriter: w, max: opts.MaxSize, head: req.Method == http.MethodHead}
+				get := req
+				if ew.head {
+					// Handlers usually write no body for HEAD: run it as GET so both get the same ETag
+					r := *req
+					r.Method = http.MethodGet
+					get = &r
+				}
+				next.ServeHTTP(exposeWrapped(ew), get)
+				ew.finish(req, opts.Weak)
+
+			case http.MethodOptions, http.MethodTrace:
+				next.ServeHTTP(w, req)
+
+			default:
+				if match := req.Header.Get("If-Match"); match != "" && !opts.matchCurrent(req, match) {
+					http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+					return
+				}
+				next.ServeHTTP(w, req)
+			}
+		})
+	}
+}
+
+// matchCurrent reports whether the current ETag of the resource matches the If-Match header.
+// Without Current the precondition can't be evaluated, so it fails.
+func (opts ETagOptions) matchCurrent(req *http.Request, match string) bool {
+	if opts.Current == nil {
+		return false
+	}
+
+	etag := opts.Current(req)
+	return etag != "" && matchETag(match, etag, false)
+}
+
+// computeETag returns an ETag for the body.
+func computeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + etag
+	}
+
+	return etag
+}
+
+// matchETag reports whether the etag is listed in an If-Match or If-None-Match header.
+// Weak comparison ignores the weak indicator, strong comparison requires both to be strong.
+func matchETag(header, etag string, weak bool) bool {
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "*" {
+			return true
+		}
+
+		if weak {
+			if strings.TrimPrefix(part, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		} else if part == etag && !strings.HasPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified reports whether the response is fresh for the conditional GET or HEAD request.
+func notModified(req *http.Request, h http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		etag := h.Get("ETag")
+		return etag != "" && matchETag(inm, etag, true)
+	}
+
+	ims, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lm, err := http.ParseTime(h.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !lm.Truncate(time.Second).After(ims)
+}
+
+// etagWriter buffers the response up to max bytes, so its ETag can be computed before sending it.
+type etagWriter struct {
+	http.ResponseWriter
+
+	// Maximum bytes buffered
+	max int
+
+	// Status code set by the handler
+	code int
+
+	// Body buffered
+	buf bytes.Buffer
+
+	// Whether the response exceeded max, or was flushed, and has been sent as is
+	overflow bool
+
+	// Whether the request is HEAD, so the body is discarded
+	head bool
+}
+
+// status returns the response status code.
+func (w *etagWriter) status() int {
+	if w.code == 0 {
+		return http.StatusOK
+	}
+
+	return w.code
+}
+
+// WriteHeader implements http.ResponseWriter. The status code is sent with the buffered response.
+func (w *etagWriter) WriteHeader(code int) {
+	if w.overflow {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+// Write implements http.ResponseWriter
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.overflow {
+		return w.send(b)
+	}
+
+	if w.buf.Len()+len(b) > w.max {
+		if err := w.stream(); err != nil {
+			return 0, err
+		}
+		return w.send(b)
+	}
+
+	return w.buf.Write(b)
+}
+
+// send writes b to the wrapped writer, or discards it for HEAD requests.
+func (w *etagWriter) send(b []byte) (int, error) {
+	if w.head {
+		return len(b), nil
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// stream gives up buffering: it sends the headers and the buffered body.
+func (w *etagWriter) stream() error {
+	w.overflow = true
+	w.ResponseWriter.WriteHeader(w.status())
+
+	_, err := w.send(w.buf.Bytes())
+	w.buf.Reset()
+
+	return err
+}
+
+// finish sets the ETag and sends the buffered response, or 304 if the client copy is fresh.
+func (w *etagWriter) finish(req *http.Request, weak bool) {
+	if w.overflow {
+		return
+	}
+
+	h := w.Header()
+	if w.status() == http.StatusOK {
+		if h.Get("ETag") == "" {
+			h.Set("ETag", computeETag(w.buf.Bytes(), weak))
+		}
+
+		if notModified(req, h) {
+			h.Del("Content-Type")
+			h.Del("Content-Length")
+			w.ResponseWriter.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	if w.head && w.status() == http.StatusOK && h.Get("Content-Length") == "" {
+		h.Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	}
+	w.ResponseWriter.WriteHeader(w.status())
+	w.send(w.buf.Bytes())
+}
+
+// Flush implements http.Flusher. Flushed responses are streamed without ETag.
+func (w *etagWriter) Flush() {
+	if !w.overflow {
+		w.stream()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// hijacked implements wrappedWriter: nothing else must be written.
+func (w *etagWriter) hijacked() {
+	w.overflow = true
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,199 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMatchETag(t *testing.T) {
+	for _, c := range []struct {
+		header, etag string
+		weak, match  bool
+	}{
+		{`"a"`, `"a"`, false, true},
+		{`"b", "a"`, `"a"`, false, true},
+		{`*`, `"a"`, false, true},
+		{`"b"`, `"a"`, true, false},
+		{`W/"a"`, `"a"`, true, true},
+		{`"a"`, `W/"a"`, true, true},
+		{`W/"a"`, `W/"a"`, false, false},
+		{`"a"`, `W/"a"`, false, false},
+	} {
+		if m := matchETag(c.header, c.etag, c.weak); m != c.match {
+			t.Errorf("%s matching %s (weak %v) should be %v", c.header, c.etag, c.weak, c.match)
+		}
+	}
+}
+
+func TestETag(t *testing.T) {
+	modified := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	r := New("/")
+	r.Add("/doc", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Write([]byte("content"))
+	})).Wrap(ETag(ETagOptions{}))
+	r.Add("/large", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(strings.Repeat("a", 200)))
+	})).Wrap(ETag(ETagOptions{MaxSize: 100, Weak: true}))
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/doc", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != "content" || !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("Response should have a strong ETag. Got %d %s %s", w.Code, w.Body.String(), etag)
+	}
+
+	for _, c := range []struct {
+		header, value string
+		code          int
+	}{
+		{"If-None-Match", etag, http.StatusNotModified},
+		{"If-None-Match", "W/" + etag, http.StatusNotModified},
+		{"If-None-Match", `"other"`, http.StatusOK},
+		{"If-Modified-Since", modified.Format(http.TimeFormat), http.StatusNotModified},
+		{"If-Modified-Since", modified.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/doc", nil)
+		req.Header.Set(c.header, c.value)
+		d.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("%s: %s should respond %d. Got %d", c.header, c.value, c.code, w.Code)
+		}
+		if c.code == http.StatusNotModified && (w.Body.Len() != 0 || w.Header().Get("ETag") != etag) {
+			t.Errorf("304 response should have no body and keep the ETag. Got %s %s", w.Body.String(), w.Header().Get("ETag"))
+		}
+	}
+
+	// Too large to buffer
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/large", nil))
+	if w.Body.Len() != 200 || w.Header().Get("ETag") != "" {
+		t.Errorf("Large responses should be sent without ETag. Got %d bytes, ETag %s", w.Body.Len(), w.Header().Get("ETag"))
+	}
+}
+
+func TestETagIfMatch(t *testing.T) {
+	content := "v1"
+	updates := 0
+
+	r := New("/")
+	r.Add("/doc", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			updates++
+			content = "v2"
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(content))
+	})).Methods("GET", "PUT").Wrap(ETag(ETagOptions{
+		Current: func(*http.Request) string {
+			return computeETag([]byte(content), false)
+		},
+	}))
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/doc", nil))
+	etag := w.Header().Get("ETag")
+
+	for _, c := range []struct {
+		match string
+		code  int
+	}{
+		{`"other"`, http.StatusPreconditionFailed},
+		{"W/" + etag, http.StatusPreconditionFailed},
+		{etag, http.StatusNoContent},
+		{etag, http.StatusPreconditionFailed},
+		{"*", http.StatusNoContent},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("PUT", "/doc", nil)
+		req.Header.Set("If-Match", c.match)
+		d.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("PUT with If-Match %s should respond %d. Got %d", c.match, c.code, w.Code)
+		}
+	}
+
+	if updates != 2 {
+		t.Errorf("Handler should have updated the resource twice. Got %d", updates)
+	}
+}
+
+func TestETagIfMatchWithoutCurrent(t *testing.T) {
+	calls := 0
+
+	r := New("/")
+	r.Add("/doc", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNoContent)
+	})).Methods("GET", "PUT").Wrap(ETag(ETagOptions{}))
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/doc", nil)
+	req.Header.Set("If-Match", "*")
+	d.ServeHTTP(w, req)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("PUT with If-Match and no Current should respond 412. Got %d", w.Code)
+	}
+	if calls != 0 {
+		t.Errorf("Handler shouldn't be called before the precondition passes. Got %d calls", calls)
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("PUT", "/doc", nil))
+	if w.Code != http.StatusNoContent || calls != 1 {
+		t.Errorf("PUT without If-Match should reach the handler. Got %d and %d calls", w.Code, calls)
+	}
+}
+
+func TestETagHijacker(t *testing.T) {
+	testHijacker(t, ETag(ETagOptions{}), func() *http.Request {
+		return httptest.NewRequest("GET", "/", nil)
+	})
+}
+
+func TestETagHead(t *testing.T) {
+	r := New("/")
+	r.Add("/a.txt", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// ServeContent writes no body for HEAD
+		http.ServeContent(w, r, "a.txt", time.Time{}, strings.NewReader("hello world"))
+	})).Wrap(ETag(ETagOptions{}))
+
+	d := Build(r)
+
+	get := httptest.NewRecorder()
+	d.ServeHTTP(get, httptest.NewRequest("GET", "/a.txt", nil))
+	etag := get.Header().Get("ETag")
+
+	head := httptest.NewRecorder()
+	d.ServeHTTP(head, httptest.NewRequest("HEAD", "/a.txt", nil))
+	if etag == "" || head.Header().Get("ETag") != etag {
+		t.Errorf("HEAD ETag should be the GET one %s. Got %s", etag, head.Header().Get("ETag"))
+	}
+	if head.Body.Len() != 0 || head.Header().Get("Content-Length") != "11" {
+		t.Errorf("HEAD response should have no body and the GET length. Got %s %q", head.Header().Get("Content-Length"), head.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("HEAD", "/a.txt", nil)
+	req.Header.Set("If-None-Match", etag)
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Conditional HEAD with the GET validator should respond 304. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,260 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCheckTimeout is the error reported for health checks that didn't finish in time.
+var ErrCheckTimeout = errors.New("router: health check timeout")
+
+// DefaultCheckTimeout is the health check timeout used when none is given.
+const DefaultCheckTimeout = 5 * time.Second
+
+// CheckFunc checks a dependency or internal state, returning nil if it's healthy.
+// The context is done when the check timeout expires.
+type CheckFunc func(ctx context.Context) error
+
+// Health aggregates named health checks and serves them on "/healthz", "/readyz" and "/livez":
+//
+//	h := router.NewHealth(5 * time.Second)
+//	h.Readiness("db", time.Second, db.PingContext)
+//	h.Liveness("deadlock", time.Second, checkWorkers)
+//	h.Register(r)
+//
+// "/livez" runs the liveness checks, "/readyz" the readiness ones and "/healthz" all of them.
+// Checks run concurrently and their results are cached for the TTL.
+// Responses are 200 if all checks passed and 503 otherwise, with JSON details.
+type Health struct {
+	// Results cache duration
+	ttl time.Duration
+
+	mu        sync.Mutex
+	readiness []*healthCheck
+	liveness  []*healthCheck
+
+	// Set once shutting down, so the service stops being ready
+	shutdown atomic.Bool
+
+	// Dispatcher whose draining makes the service not ready, if any
+	dispatcher Dispatcher
+}
+
+// NewHealth creates a Health caching check results for ttl. Zero runs the checks on every request.
+func NewHealth(ttl time.Duration) *Health {
+	return &Health{ttl: ttl}
+}
+
+// healthCheck is a named check with its cached result.
+type healthCheck struct {
+	name    string
+	timeout time.Duration
+	check   CheckFunc
+
+	mu      sync.Mutex
+	result  checkResult
+	checked time.Time
+
+	// Closed when the running check ends, nil if none is running
+	running chan struct{}
+
+	// Whether the check function didn't return yet, which may outlast its timeout if it ignores its context
+	busy bool
+}
+
+// newHealthCheck creates a check, with DefaultCheckTimeout if timeout isn't positive.
+func newHealthCheck(name string, timeout time.Duration, check CheckFunc) *healthCheck {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+
+	return &healthCheck{name: name, timeout: timeout, check: check}
+}
+
+// checkResult is the JSON detail of a check.
+type checkResult struct {
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// healthResult is the JSON response of the health endpoints.
+type healthResult struct {
+	Status   string                 `json:"status"`
+	Shutdown bool                   `json:"shutdown,omitempty"`
+	Checks   map[string]checkResult `json:"checks"`
+}
+
+// Readiness adds a check that must pass for the service to receive traffic, e.g. a database ping.
+// Checks taking longer than timeout, DefaultCheckTimeout if not positive, fail with ErrCheckTimeout.
+func (h *Health) Readiness(name string, timeout time.Duration, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.readiness = append(h.readiness, newHealthCheck(name, timeout, check))
+}
+
+// Liveness adds a check that must pass for the service to be considered alive, failing only if it must be restarted.
+func (h *Health) Liveness(name string, timeout time.Duration, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.liveness = append(h.liveness, newHealthCheck(name, timeout, check))
+}
+
+// Shutdown makes the service not ready, so load balancers stop sending it requests while it shuts down.
+// It's meant to be called when graceful shutdown starts, e.g. through http.Server.RegisterOnShutdown.
+func (h *Health) Shutdown() {
+	h.shutdown.Store(true)
+}
+
+// Watch makes the service not ready while the Dispatcher is draining. See Dispatcher.Drain.
+func (h *Health) Watch(d Dispatcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.dispatcher = d
+}
+
+// Register adds the "/healthz", "/readyz" and "/livez" routes to the router.
+func (h *Health) Register(r Router) {
+	r.Add("/healthz", h.handler(true, true)).Methods(http.MethodGet, http.MethodHead)
+	r.Add("/readyz", h.handler(true, false)).Methods(http.MethodGet, http.MethodHead)
+	r.Add("/livez", h.handler(false, true)).Methods(http.MethodGet, http.MethodHead)
+}
+
+// handler returns the handler running the readiness and/or liveness checks.
+func (h *Health) handler(readiness, liveness bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.mu.Lock()
+		checks := make([]*healthCheck, 0, len(h.readiness)+len(h.liveness))
+		if readiness {
+			checks = append(checks, h.readiness...)
+		}
+		if liveness {
+			checks = append(checks, h.liveness...)
+		}
+		d := h.dispatcher
+		h.mu.Unlock()
+
+		res := healthResult{Status: "ok", Checks: make(map[string]checkResult, len(checks))}
+		results := make([]checkResult, len(checks))
+
+		var wg sync.WaitGroup
+		for i, c := range checks {
+			wg.Add(1)
+			go func(i int, c *healthCheck) {
+				defer wg.Done()
+				results[i] = c.run(req.Context(), h.ttl)
+			}(i, c)
+		}
+		wg.Wait()
+
+		for i, c := range checks {
+			res.Checks[c.name] = results[i]
+			if results[i].Status != "ok" {
+				res.Status = "fail"
+			}
+		}
+
+		if readiness && (h.shutdown.Load() || (d != nil && d.Draining())) {
+			res.Status = "fail"
+			res.Shutdown = true
+		}
+
+		code := http.StatusOK
+		if res.Status != "ok" {
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(res)
+	})
+}
+
+// run returns the cached check result, or runs the check if it expired.
+// Concurrent callers wait for the running check instead of running it again, and stop waiting when their context is done.
+func (c *healthCheck) run(ctx context.Context, ttl time.Duration) checkResult {
+	c.mu.Lock()
+	if !c.checked.IsZero() && time.Since(c.checked) < ttl {
+		defer c.mu.Unlock()
+		return c.result
+	}
+
+	if c.running == nil {
+		if c.busy {
+			// The last check ignored its timeout and is still running: don't pile up another one
+			defer c.mu.Unlock()
+			c.result = checkResult{Status: "fail", Error: ErrCheckTimeout.Error(), Duration: "0s"}
+			c.checked = time.Now()
+			return c.result
+		}
+
+		c.running = make(chan struct{})
+		c.busy = true
+		go c.exec(c.running)
+	}
+	done := c.running
+	c.mu.Unlock()
+
+	start := time.Now()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		// The request went away: the check keeps running for the other callers
+		return checkResult{Status: "fail", Error: ctx.Err().Error(), Duration: time.Since(start).String()}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.result
+}
+
+// exec runs the check with its timeout, caches the result and closes done.
+// The check doesn't depend on any request context, since several requests may be waiting for it.
+func (c *healthCheck) exec(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() {
+		err := c.check(ctx)
+
+		c.mu.Lock()
+		c.busy = false
+		c.mu.Unlock()
+
+		errc <- err
+	}()
+
+	// Don't wait for checks ignoring their context
+	var err error
+	select {
+	case err = <-errc:
+	case <-ctx.Done():
+		err = ErrCheckTimeout
+	}
+
+	res := checkResult{Status: "ok", Duration: time.Since(start).String()}
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.result = res
+	c.checked = time.Now()
+	c.running = nil
+	close(done)
+}
@@ -0,0 +1,164 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	var dbErr error
+
+	h := NewHealth(0)
+	h.Readiness("db", time.Second, func(ctx context.Context) error {
+		return dbErr
+	})
+	h.Readiness("slow", 10*time.Millisecond, func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	h.Liveness("workers", time.Second, func(ctx context.Context) error {
+		return nil
+	})
+
+	r := New("/")
+	h.Register(r)
+	d := Build(r)
+
+	for _, c := range []struct {
+		path   string
+		code   int
+		checks map[string]string
+	}{
+		{"/livez", http.StatusOK, map[string]string{"workers": "ok"}},
+		{"/readyz", http.StatusServiceUnavailable, map[string]string{"db": "ok", "slow": "fail"}},
+		{"/healthz", http.StatusServiceUnavailable, map[string]string{"db": "ok", "slow": "fail", "workers": "ok"}},
+	} {
+		w := httptest.NewRecorder()
+		start := time.Now()
+		d.ServeHTTP(w, httptest.NewRequest("GET", c.path, nil))
+
+		if time.Since(start) > 500*time.Millisecond {
+			t.Errorf("%s should not wait for checks past their timeout", c.path)
+		}
+		if w.Code != c.code {
+			t.Errorf("%s should respond %d. Got %d", c.path, c.code, w.Code)
+		}
+
+		var res healthResult
+		if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+		if len(res.Checks) != len(c.checks) {
+			t.Errorf("%s should run %d checks. Got %+v", c.path, len(c.checks), res.Checks)
+		}
+		for name, status := range c.checks {
+			if res.Checks[name].Status != status {
+				t.Errorf("%s check %s should be %s. Got %+v", c.path, name, status, res.Checks[name])
+			}
+		}
+	}
+
+	// Failing check detail
+	dbErr = errors.New("connection refused")
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+
+	var res healthResult
+	json.Unmarshal(w.Body.Bytes(), &res)
+	if res.Checks["db"].Error != "connection refused" || res.Checks["slow"].Error != ErrCheckTimeout.Error() {
+		t.Errorf("Failed checks should report their error. Got %+v", res.Checks)
+	}
+}
+
+func TestHealthCache(t *testing.T) {
+	var runs int32
+
+	h := NewHealth(time.Hour)
+	h.Readiness("db", time.Second, func(ctx context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	r := New("/")
+	h.Register(r)
+	d := Build(r)
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("/readyz should respond 200. Got %d", w.Code)
+		}
+	}
+
+	if runs != 1 {
+		t.Errorf("Check result should be cached. Got %d runs", runs)
+	}
+}
+
+func TestHealthStuckCheck(t *testing.T) {
+	var runs int32
+	release := make(chan struct{})
+	defer close(release)
+
+	h := NewHealth(0)
+	h.Readiness("stuck", 0, func(ctx context.Context) error {
+		// Ignores its context
+		atomic.AddInt32(&runs, 1)
+		<-release
+		return nil
+	})
+	c := h.readiness[0]
+	if c.timeout != DefaultCheckTimeout {
+		t.Errorf("Checks without timeout should get DefaultCheckTimeout. Got %s", c.timeout)
+	}
+	c.timeout = 20 * time.Millisecond
+
+	// Waiting callers can give up before the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if res := c.run(ctx, 0); res.Status != "fail" || res.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("Caller giving up should get its context error. Got %+v", res)
+	}
+	if time.Since(start) >= c.timeout {
+		t.Error("Caller giving up shouldn't wait for the check timeout")
+	}
+
+	for i := 0; i < 3; i++ {
+		if res := c.run(context.Background(), 0); res.Status != "fail" || res.Error != ErrCheckTimeout.Error() {
+			t.Errorf("Stuck check should fail with ErrCheckTimeout. Got %+v", res)
+		}
+	}
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("Stuck check shouldn't be started again while it's running. Got %d runs", n)
+	}
+}
+
+func TestHealthShutdown(t *testing.T) {
+	h := NewHealth(0)
+
+	r := New("/")
+	h.Register(r)
+	d := Build(r)
+
+	h.Shutdown()
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Service shouldn't be ready while shutting down. Got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/livez", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Service should still be alive while shutting down. Got %d", w.Code)
+	}
+}
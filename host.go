@@ -0,0 +1,71 @@
+package router
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// host holds a parsed host pattern a router is bound to.
+// Each label can be static ("api"), a parameter (":tenant")
+// or, only as the leftmost label, a wildcard ("*") matching one or more labels.
+type host struct {
+	labels []string
+}
+
+// parseHost splits a host pattern into its labels.
+func parseHost(pattern string) *host {
+	return &host{
+		labels: strings.Split(strings.ToLower(strings.Trim(pattern, ".")), "."),
+	}
+}
+
+// match checks the request host against the pattern and sets the host parameters found.
+// Ports are ignored and static labels are compared case-insensitively, as hosts are.
+func (h *host) match(r *http.Request, params map[string]string) bool {
+	name := r.Host
+	if name == "" && r.URL != nil {
+		name = r.URL.Host
+	}
+	if hn, _, err := net.SplitHostPort(name); err == nil {
+		name = hn
+	}
+
+	labels := strings.Split(strings.Trim(name, "."), ".")
+
+	// Wildcard takes all remaining leading labels
+	if h.labels[0] == "*" {
+		if len(labels) < len(h.labels) {
+			return false
+		}
+		labels = labels[len(labels)-len(h.labels)+1:]
+		return h.matchLabels(h.labels[1:], labels, params)
+	}
+
+	if len(labels) != len(h.labels) {
+		return false
+	}
+
+	return h.matchLabels(h.labels, labels, params)
+}
+
+// matchLabels compares label by label, collecting parameters in a separate map
+// so params is only modified on a full match.
+func (h *host) matchLabels(pattern, labels []string, params map[string]string) bool {
+	found := make(map[string]string)
+	for i, l := range pattern {
+		if len(l) > 1 && l[0] == ':' {
+			found[l[1:]] = labels[i]
+			continue
+		}
+		if !strings.EqualFold(l, labels[i]) {
+			return false
+		}
+	}
+
+	for k, v := range found {
+		params[k] = v
+	}
+
+	return true
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostMatch(t *testing.T) {
+	r := New("/")
+	r.Host("api.example.com")
+	r.Add("/users", http.HandlerFunc(handler))
+
+	for _, match := range []string{
+		"http://api.example.com/users",
+		"http://API.example.com:8080/users",
+	} {
+		req := httptest.NewRequest("GET", match, nil)
+		if h := r.Match(req); h == nil {
+			t.Errorf("%s should have matched our routes", match)
+		}
+	}
+
+	for _, nomatch := range []string{
+		"http://example.com/users",
+		"http://web.example.com/users",
+		"http://v2.api.example.com/users",
+	} {
+		req := httptest.NewRequest("GET", nomatch, nil)
+		if h := r.Match(req); h != nil {
+			t.Errorf("%s shouldn't have matched our routes", nomatch)
+		}
+	}
+}
+
+func TestHostParam(t *testing.T) {
+	r := New("/")
+	r.Host(":tenant.example.com")
+	r.Add("/users/:id", http.HandlerFunc(handler))
+	r.Add("/", http.HandlerFunc(handler))
+
+	req := httptest.NewRequest("GET", "http://acme.example.com/users/42", nil)
+	if h := r.Match(req); h == nil {
+		t.Fatalf("%s should have matched our routes", req.URL)
+	}
+	if Param(req, "tenant") != "acme" {
+		t.Errorf("Param :tenant should be set to 'acme'. Got %s", Param(req, "tenant"))
+	}
+	if Param(req, "id") != "42" {
+		t.Errorf("Param :id should be set to '42'. Got %s", Param(req, "id"))
+	}
+
+	req = httptest.NewRequest("GET", "http://acme.example.com/", nil)
+	if h := r.Match(req); h == nil {
+		t.Fatalf("%s should have matched our routes", req.URL)
+	}
+	if Param(req, "tenant") != "acme" {
+		t.Errorf("Param :tenant should be set to 'acme' on root route. Got %s", Param(req, "tenant"))
+	}
+}
+
+func TestHostWildcard(t *testing.T) {
+	r := New("/")
+	r.Host("*.example.com")
+	r.Add("/", http.HandlerFunc(handler))
+
+	for _, match := range []string{
+		"http://a.example.com/",
+		"http://a.b.example.com/",
+	} {
+		req := httptest.NewRequest("GET", match, nil)
+		if h := r.Match(req); h == nil {
+			t.Errorf("%s should have matched our routes", match)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	if h := r.Match(req); h != nil {
+		t.Errorf("%s shouldn't have matched our routes", req.URL)
+	}
+}
+
+func TestHostDispatch(t *testing.T) {
+	api := New("/")
+	api.Host("api.example.com")
+	api.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("api"))
+	}))
+	api.Wrap(func(next http.Handler) http.Handler {
+		return next
+	})
+
+	tenants := New("/")
+	tenants.Host(":tenant.example.com")
+	tenants.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Param(r, "tenant")))
+	}))
+
+	d := Build(api, tenants)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "http://api.example.com/", nil))
+	if w.Body.String() != "api" {
+		t.Errorf("Response body should be 'api'. Got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "http://acme.example.com/", nil))
+	if w.Body.String() != "acme" {
+		t.Errorf("Response body should be 'acme'. Got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,348 @@
+package router
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// JWK is a parsed JSON Web Key.
+type JWK struct {
+	// KeyID is the "kid" parameter
+	KeyID string
+
+	// Algorithm is the "alg" parameter, empty if the key doesn't restrict it
+	Algorithm string
+
+	// Key is a *rsa.PublicKey, an *ecdsa.PublicKey or the []byte secret of an "oct" key
+	Key interface{}
+}
+
+// usable reports whether the key can verify signatures made with alg.
+func (k *JWK) usable(alg string) bool {
+	if k.Algorithm != "" && k.Algorithm != alg {
+		return false
+	}
+
+	switch key := k.Key.(type) {
+	case []byte:
+		return alg == "HS256"
+	case *rsa.PublicKey:
+		return alg == "RS256"
+	case *ecdsa.PublicKey:
+		return alg == "ES256" && key.Curve == elliptic.P256()
+	}
+
+	return false
+}
+
+// KeySet provides the keys to verify JWT signatures.
+type KeySet interface {
+	// Key returns the key identified by kid, usable with alg.
+	// If kid is empty, the first key usable with alg is returned.
+	Key(ctx context.Context, kid, alg string) (*JWK, error)
+}
+
+// ErrKeyNotFound is returned by KeySet implementations when no key matches.
+var ErrKeyNotFound = errors.New("router: key not found")
+
+// JWKS is a static JSON Web Key Set.
+type JWKS struct {
+	keys []*JWK
+}
+
+// NewJWKS creates a key set from keys, e.g. to verify HS256 tokens with a shared secret:
+//
+//	router.NewJWKS(&router.JWK{Key: []byte(secret)})
+func NewJWKS(keys ...*JWK) *JWKS {
+	return &JWKS{keys: keys}
+}
+
+// Key implements KeySet
+func (s *JWKS) Key(ctx context.Context, kid, alg string) (*JWK, error) {
+	for _, k := range s.keys {
+		if (kid == "" || k.KeyID == kid) && k.usable(alg) {
+			return k, nil
+		}
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// LoadJWKS reads a JSON Web Key Set file.
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
+
+// ParseJWKS parses a JSON Web Key Set.
+// Keys of unsupported types or curves are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var raw struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Alg string `json:"alg"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+			K   string `json:"k"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("router: invalid JWKS: %w", err)
+	}
+
+	s := &JWKS{keys: make([]*JWK, 0, len(raw.Keys))}
+	for _, k := range raw.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		jwk := &JWK{KeyID: k.Kid, Algorithm: k.Alg}
+		switch k.Kty {
+		case "RSA":
+			n, err1 := decodeBigInt(k.N)
+			e, err2 := decodeBigInt(k.E)
+			if err1 != nil || err2 != nil || !e.IsInt64() {
+				return nil, fmt.Errorf("router: invalid RSA key %q", k.Kid)
+			}
+			jwk.Key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+
+		case "EC":
+			if k.Crv != "P-256" {
+				continue
+			}
+			x, err1 := decodeBigInt(k.X)
+			y, err2 := decodeBigInt(k.Y)
+			if err1 != nil || err2 != nil {
+				return nil, fmt.Errorf("router: invalid EC key %q", k.Kid)
+			}
+			jwk.Key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+
+		case "oct":
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil {
+				return nil, fmt.Errorf("router: invalid oct key %q", k.Kid)
+			}
+			jwk.Key = secret
+
+		default:
+			continue
+		}
+
+		s.keys = append(s.keys, jwk)
+	}
+
+	return s, nil
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer.
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("router: empty integer")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// HTTPClient sends HTTP requests. *http.Client implements it.
+type HTTPClient interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// DefaultJWKSTTL is the remote key sets cache duration used when none is given.
+const DefaultJWKSTTL = time.Hour
+
+// NewRemoteJWKS creates a KeySet fetching the JSON Web Key Set from url with client,
+// or http.DefaultClient if nil. The set is cached for ttl, DefaultJWKSTTL if not positive,
+// and fetched again before that if a key isn't found, so rotated keys are picked up.
+// Expired sets keep being used while they are refreshed and when the refresh fails.
+func NewRemoteJWKS(url string, client HTTPClient, ttl time.Duration) KeySet {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if ttl <= 0 {
+		ttl = DefaultJWKSTTL
+	}
+
+	return &remoteJWKS{
+		url:        url,
+		client:     client,
+		ttl:        ttl,
+		minRefresh: minRefresh,
+	}
+}
+
+// minRefresh limits how often the key set is fetched again, for unknown keys or after failures.
+const minRefresh = 10 * time.Second
+
+// fetchTimeout limits the key set fetch duration.
+const fetchTimeout = 30 * time.Second
+
+// remoteJWKS implements KeySet with a remote JWKS.
+type remoteJWKS struct {
+	url        string
+	client     HTTPClient
+	ttl        time.Duration
+	minRefresh time.Duration
+
+	mu sync.Mutex
+
+	// Last key set fetched and when
+	set     *JWKS
+	fetched time.Time
+
+	// Last fetch attempt and its error
+	attempted time.Time
+	err       error
+
+	// Closed when the running fetch ends, nil if none is running
+	refreshing chan struct{}
+}
+
+// Key implements KeySet
+func (s *remoteJWKS) Key(ctx context.Context, kid, alg string) (*JWK, error) {
+	s.mu.Lock()
+	set, lastErr := s.set, s.err
+	recent := time.Since(s.attempted) <= s.minRefresh
+	if set != nil && time.Since(s.fetched) > s.ttl && !recent {
+		// Keep serving the expired keys while refreshing them
+		s.refresh()
+	}
+	failed := set == nil && s.refreshing == nil && lastErr != nil && recent
+	s.mu.Unlock()
+
+	if failed {
+		// Don't hit a failing endpoint on every request
+		return nil, lastErr
+	}
+	if set == nil {
+		var err error
+		if set, err = s.wait(ctx); set == nil {
+			return nil, err
+		}
+	}
+
+	k, err := set.Key(ctx, kid, alg)
+	if err == ErrKeyNotFound {
+		s.mu.Lock()
+		retry := s.refreshing != nil || time.Since(s.attempted) > s.minRefresh
+		s.mu.Unlock()
+
+		if retry {
+			if set, _ := s.wait(ctx); set != nil {
+				k, err = set.Key(ctx, kid, alg)
+			}
+		}
+	}
+
+	return k, err
+}
+
+// wait starts a fetch, unless one is running, and waits for it to end or the context to be done.
+// It returns the latest key set, which may be the previous one if the fetch failed, and the fetch error if there's none.
+func (s *remoteJWKS) wait(ctx context.Context) (*JWKS, error) {
+	s.mu.Lock()
+	done := s.refresh()
+	s.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.set != nil {
+		return s.set, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, s.err
+}
+
+// refresh starts fetching the key set in the background, unless it's already being fetched,
+// so a slow endpoint doesn't hold the lock. It returns a channel closed when the fetch ends.
+// Must be called with the lock held.
+func (s *remoteJWKS) refresh() <-chan struct{} {
+	if s.refreshing != nil {
+		return s.refreshing
+	}
+
+	done := make(chan struct{})
+	s.refreshing = done
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+		defer cancel()
+
+		set, err := s.fetch(ctx)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.attempted = time.Now()
+		s.err = err
+		if err == nil {
+			s.set = set
+			s.fetched = s.attempted
+		}
+		s.refreshing = nil
+		close(done)
+	}()
+
+	return done
+}
+
+// fetch downloads and parses the key set.
+func (s *remoteJWKS) fetch(ctx context.Context) (*JWKS, error) {
+	req, err := http.NewRequest("GET", s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("router: fetching JWKS: %s", res.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseJWKS(data)
+}
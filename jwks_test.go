@@ -0,0 +1,215 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// testJWKS returns a JWKS document for the keys.
+func testJWKS(rsaKey *rsa.PublicKey, ecKey *ecdsa.PublicKey) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"keys": []map[string]string{
+			{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{"kty": "EC", "kid": "ec", "crv": "P-256", "x": b64(ecKey.X.Bytes()), "y": b64(ecKey.Y.Bytes())},
+			{"kty": "oct", "kid": "hmac", "k": b64([]byte("secret"))},
+			{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(rsaKey.N.Bytes()), "e": "AQAB"},
+			{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"},
+		},
+	})
+
+	return data
+}
+
+func TestLoadJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, testJWKS(&rsaKey.PublicKey, &ecKey.PublicKey), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatalf("JWKS should load. Got %s", err)
+	}
+	if len(set.keys) != 3 {
+		t.Fatalf("JWKS should have 3 signing keys. Got %d", len(set.keys))
+	}
+
+	ctx := context.Background()
+	if k, err := set.Key(ctx, "rsa", "RS256"); err != nil || k.Key.(*rsa.PublicKey).N.Cmp(rsaKey.N) != 0 {
+		t.Errorf("RSA key should be found. Got %v", err)
+	}
+	if k, err := set.Key(ctx, "ec", "ES256"); err != nil || !k.Key.(*ecdsa.PublicKey).Equal(&ecKey.PublicKey) {
+		t.Errorf("EC key should be found. Got %v", err)
+	}
+	if k, err := set.Key(ctx, "", "HS256"); err != nil || string(k.Key.([]byte)) != "secret" {
+		t.Errorf("HMAC key should be found. Got %v", err)
+	}
+	if _, err := set.Key(ctx, "rsa", "ES256"); err != ErrKeyNotFound {
+		t.Errorf("RSA key shouldn't be usable for ES256. Got %v", err)
+	}
+
+	if _, err := ParseJWKS([]byte("{")); err == nil {
+		t.Error("Invalid JWKS shouldn't be parsed")
+	}
+}
+
+func TestRemoteJWKS(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	fetches := 0
+	jwks := New("/")
+	jwks.Add("/.well-known/jwks.json", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.Write(testJWKS(&rsaKey.PublicKey, &ecKey.PublicKey))
+	}))
+
+	srv := httptest.NewServer(Build(jwks))
+	defer srv.Close()
+
+	api := New("/")
+	api.Add("/", http.HandlerFunc(handler))
+	api.Wrap(JWT(JWTOptions{
+		Keys: NewRemoteJWKS(srv.URL+"/.well-known/jwks.json", srv.Client(), time.Hour),
+	}))
+
+	d := Build(api)
+	claims := map[string]interface{}{"sub": "joe"}
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, "ES256", "ec", ecKey, claims))
+		d.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("Token should be valid with the remote JWKS. Got %d", w.Code)
+		}
+	}
+
+	if fetches != 1 {
+		t.Errorf("JWKS should have been fetched once. Got %d", fetches)
+	}
+}
+
+// jwksClient is an HTTPClient serving a JWKS document, counting the fetches.
+type jwksClient struct {
+	data    []byte
+	delay   time.Duration
+	fail    atomic.Bool
+	fetches atomic.Int32
+}
+
+func (c *jwksClient) Do(req *http.Request) (*http.Response, error) {
+	c.fetches.Add(1)
+	time.Sleep(c.delay)
+
+	if c.fail.Load() {
+		return nil, errors.New("connection refused")
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Body:       io.NopCloser(bytes.NewReader(c.data)),
+	}, nil
+}
+
+func TestRemoteJWKSRefresh(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ctx := context.Background()
+
+	// Concurrent requests share a single fetch
+	client := &jwksClient{data: testJWKS(&rsaKey.PublicKey, &ecKey.PublicKey), delay: 20 * time.Millisecond}
+	set := NewRemoteJWKS("https://auth.example.com/jwks.json", client, 0).(*remoteJWKS)
+
+	if set.ttl != DefaultJWKSTTL {
+		t.Errorf("TTL should default to %s. Got %s", DefaultJWKSTTL, set.ttl)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := set.Key(ctx, "ec", "ES256"); err != nil {
+				t.Errorf("Key should be found. Got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := client.fetches.Load(); n != 1 {
+		t.Errorf("Concurrent requests should fetch the JWKS once. Got %d", n)
+	}
+
+	// Unknown keys refetch at most once per minRefresh
+	for i := 0; i < 3; i++ {
+		if _, err := set.Key(ctx, "unknown", "ES256"); err != ErrKeyNotFound {
+			t.Errorf("Unknown key shouldn't be found. Got %v", err)
+		}
+	}
+	if n := client.fetches.Load(); n != 1 {
+		t.Errorf("Unknown keys shouldn't refetch the JWKS before minRefresh. Got %d fetches", n)
+	}
+
+	set.minRefresh = 0
+	set.Key(ctx, "unknown", "ES256")
+	if n := client.fetches.Load(); n != 2 {
+		t.Errorf("Unknown key should refetch the JWKS after minRefresh. Got %d fetches", n)
+	}
+
+	// Expired keys keep being served when the refresh fails
+	client.fail.Store(true)
+	set.ttl = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		if _, err := set.Key(ctx, "ec", "ES256"); err != nil {
+			t.Errorf("Expired key should be served while the JWKS can't be fetched. Got %v", err)
+		}
+		time.Sleep(30 * time.Millisecond)
+	}
+	if n := client.fetches.Load(); n < 3 {
+		t.Errorf("Expired JWKS should be refreshed. Got %d fetches", n)
+	}
+}
+
+func TestRemoteJWKSUnavailable(t *testing.T) {
+	client := &jwksClient{}
+	client.fail.Store(true)
+	set := NewRemoteJWKS("https://auth.example.com/jwks.json", client, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if _, err := set.Key(context.Background(), "ec", "ES256"); err == nil {
+			t.Error("Key shouldn't be found without JWKS")
+		}
+	}
+	if n := client.fetches.Load(); n != 1 {
+		t.Errorf("Failing JWKS endpoint shouldn't be fetched on every request. Got %d", n)
+	}
+}
@@ -0,0 +1,200 @@
+package router
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type claimsKey struct{}
+
+// Claims returns the claims of the JWT validated by the JWT Middleware, or nil if there's none.
+func Claims(req *http.Request) map[string]interface{} {
+	if c, ok := req.Context().Value(claimsKey{}).(map[string]interface{}); ok {
+		return c
+	}
+
+	return nil
+}
+
+// JWTOptions configures the JWT Middleware.
+type JWTOptions struct {
+	// Keys verifying the token signatures
+	Keys KeySet
+
+	// Algorithms accepted: "HS256", "RS256" and/or "ES256". All of them by default.
+	Algorithms []string
+
+	// Issuer, if not empty, must match the "iss" claim
+	Issuer string
+
+	// Audience, if not empty, must be in the "aud" claim
+	Audience string
+
+	// Leeway is the clock skew tolerated on "exp" and "nbf" claims
+	Leeway time.Duration
+}
+
+// JWT errors
+var (
+	ErrTokenMalformed = errors.New("router: malformed token")
+	ErrTokenAlgorithm = errors.New("router: token algorithm not allowed")
+	ErrTokenSignature = errors.New("router: invalid token signature")
+	ErrTokenExpired   = errors.New("router: token expired")
+	ErrTokenNotValid  = errors.New("router: token not valid yet")
+	ErrTokenIssuer    = errors.New("router: invalid token issuer")
+	ErrTokenAudience  = errors.New("router: invalid token audience")
+)
+
+// JWT returns a Middleware validating JSON Web Tokens from "Authorization: Bearer <token>" headers.
+// The claims are available through Claims, and are also set as the request Principal.
+// Invalid or missing tokens get a 401 Unauthorized response.
+func JWT(opts JWTOptions) Middleware {
+	if len(opts.Algorithms) == 0 {
+		opts.Algorithms = []string{"HS256", "RS256", "ES256"}
+	}
+
+	auth := BearerAuth(func(ctx context.Context, token string) (interface{}, error) {
+		return opts.verify(ctx, token, time.Now())
+	})
+
+	return func(next http.Handler) http.Handler {
+		return auth(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			*req = *req.WithContext(context.WithValue(req.Context(), claimsKey{}, Principal(req)))
+
+			next.ServeHTTP(w, req)
+		}))
+	}
+}
+
+// verify parses and validates a token, returning its claims.
+func (opts JWTOptions) verify(ctx context.Context, token string, now time.Time) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if !contains(opts.Algorithms, header.Alg) {
+		return nil, ErrTokenAlgorithm
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	key, err := opts.Keys.Key(ctx, header.Kid, header.Alg)
+	if err != nil {
+		return nil, err
+	}
+	if !verifySignature(header.Alg, key.Key, parts[0]+"."+parts[1], sig) {
+		return nil, ErrTokenSignature
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	// Time claims
+	if exp, ok := claims["exp"].(float64); ok && now.After(unixTime(exp).Add(opts.Leeway)) {
+		return nil, ErrTokenExpired
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(opts.Leeway).Before(unixTime(nbf)) {
+		return nil, ErrTokenNotValid
+	}
+
+	if opts.Issuer != "" && claims["iss"] != opts.Issuer {
+		return nil, ErrTokenIssuer
+	}
+	if opts.Audience != "" && !hasAudience(claims["aud"], opts.Audience) {
+		return nil, ErrTokenAudience
+	}
+
+	return claims, nil
+}
+
+// decodeSegment decodes a base64url JSON token segment into v.
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// unixTime converts a NumericDate to time.Time.
+func unixTime(f float64) time.Time {
+	sec := int64(f)
+
+	return time.Unix(sec, int64((f-float64(sec))*1e9))
+}
+
+// hasAudience reports whether the "aud" claim, a string or an array of strings, contains aud.
+func hasAudience(claim interface{}, aud string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == aud
+	case []interface{}:
+		for _, a := range v {
+			if a == aud {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// verifySignature checks the signature of the signed input with the key, matching the key type to alg
+// so a public key can't be used as an HMAC secret.
+func verifySignature(alg string, key interface{}, input string, sig []byte) bool {
+	sum := sha256.Sum256([]byte(input))
+
+	switch alg {
+	case "HS256":
+		secret, ok := key.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(input))
+		return hmac.Equal(sig, mac.Sum(nil))
+
+	case "RS256":
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig) == nil
+
+	case "ES256":
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok || len(sig) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:32])
+		s := new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, sum[:], r, s)
+	}
+
+	return false
+}
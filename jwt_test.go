@@ -0,0 +1,160 @@
+package router
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// signToken creates a JWT signed with key for the tests.
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(input))
+
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		var err error
+		if sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum[:]); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, sum[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig = make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	secret := []byte("secret")
+
+	keys := NewJWKS(
+		&JWK{KeyID: "rsa", Key: &rsaKey.PublicKey},
+		&JWK{KeyID: "ec", Key: &ecKey.PublicKey},
+		&JWK{KeyID: "hmac", Key: secret},
+	)
+
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(Claims(r)["sub"].(string)))
+	}))
+	r.Wrap(JWT(JWTOptions{Keys: keys}))
+
+	d := Build(r)
+	claims := map[string]interface{}{"sub": "joe", "exp": time.Now().Add(time.Hour).Unix()}
+
+	for _, c := range []struct {
+		alg, kid string
+		key      interface{}
+	}{
+		{"RS256", "rsa", rsaKey},
+		{"ES256", "ec", ecKey},
+		{"HS256", "hmac", secret},
+		{"HS256", "", secret},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+signToken(t, c.alg, c.kid, c.key, claims))
+		d.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK || w.Body.String() != "joe" {
+			t.Errorf("%s token should be valid. Got %d %s", c.alg, w.Code, w.Body.String())
+		}
+	}
+
+	// Wrong key
+	other, _ := rsa.GenerateKey(rand.Reader, 2048)
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+signToken(t, "RS256", "rsa", other, claims))
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Token signed with another key should respond 401. Got %d", w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") != `Bearer error="invalid_token"` {
+		t.Errorf("WWW-Authenticate header isn't as expected. Got %s", w.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestJWTAlgorithmConfusion(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	opts := JWTOptions{Keys: NewJWKS(&JWK{KeyID: "rsa", Key: &rsaKey.PublicKey})}
+
+	// HS256 token using the RSA public key as secret
+	token := signToken(t, "HS256", "rsa", rsaKey.PublicKey.N.Bytes(), map[string]interface{}{"sub": "joe"})
+	if _, err := opts.verify(context.Background(), token, time.Now()); err == nil {
+		t.Error("HS256 token shouldn't be verified with an RSA key")
+	}
+
+	// Algorithm not allowed
+	opts.Algorithms = []string{"ES256"}
+	token = signToken(t, "RS256", "rsa", rsaKey, map[string]interface{}{"sub": "joe"})
+	if _, err := opts.verify(context.Background(), token, time.Now()); err != ErrTokenAlgorithm {
+		t.Errorf("RS256 token should fail with ErrTokenAlgorithm. Got %v", err)
+	}
+
+	// None
+	token = "eyJhbGciOiJub25lIn0.eyJzdWIiOiJqb2UifQ."
+	if _, err := opts.verify(context.Background(), token, time.Now()); err != ErrTokenAlgorithm {
+		t.Errorf("Unsigned token should fail with ErrTokenAlgorithm. Got %v", err)
+	}
+}
+
+func TestJWTClaimsValidation(t *testing.T) {
+	secret := []byte("secret")
+	opts := JWTOptions{
+		Keys:       NewJWKS(&JWK{Key: secret}),
+		Algorithms: []string{"HS256"},
+		Issuer:     "https://auth.example.com",
+		Audience:   "api",
+		Leeway:     30 * time.Second,
+	}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		claims map[string]interface{}
+		err    error
+	}{
+		{map[string]interface{}{"iss": "https://auth.example.com", "aud": "api", "exp": now.Add(-10 * time.Second).Unix()}, nil},
+		{map[string]interface{}{"iss": "https://auth.example.com", "aud": []string{"web", "api"}, "nbf": now.Add(10 * time.Second).Unix()}, nil},
+		{map[string]interface{}{"iss": "https://auth.example.com", "aud": "api", "exp": now.Add(-time.Minute).Unix()}, ErrTokenExpired},
+		{map[string]interface{}{"iss": "https://auth.example.com", "aud": "api", "nbf": now.Add(time.Minute).Unix()}, ErrTokenNotValid},
+		{map[string]interface{}{"iss": "https://evil.com", "aud": "api"}, ErrTokenIssuer},
+		{map[string]interface{}{"iss": "https://auth.example.com", "aud": "web"}, ErrTokenAudience},
+		{map[string]interface{}{"iss": "https://auth.example.com"}, ErrTokenAudience},
+	} {
+		_, err := opts.verify(context.Background(), signToken(t, "HS256", "", secret, c.claims), now)
+		if err != c.err {
+			t.Errorf("Claims %v should fail with %v. Got %v", c.claims, c.err, err)
+		}
+	}
+
+	if _, err := opts.verify(context.Background(), "not.a.token", now); err != ErrTokenMalformed {
+		t.Errorf("Malformed token should fail with ErrTokenMalformed. Got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"sync/atomic"
+)
+
+// bodyLimits returns the maximum body size and accepted content types for the route,
+// falling back to the router ones.
+func (rt *Route) bodyLimits() (int64, []string) {
+	size, types := rt.maxBodySize, rt.accepts
+	if rt.router != nil {
+		if size == 0 {
+			size = rt.router.maxBodySize
+		}
+		if types == nil {
+			types = rt.router.accepts
+		}
+	}
+
+	return size, types
+}
+
+// limit is the Middleware enforcing the route body size and content types.
+// Requests with a body whose media type isn't accepted are answered with 415 Unsupported Media Type,
+// and those declaring a larger Content-Length with 413 Request Entity Too Large.
+// Bodies without Content-Length are wrapped with http.MaxBytesReader, so reading past the limit fails with *http.MaxBytesError,
+// and the response is replaced with 413 Request Entity Too Large unless the handler started writing it before.
+func (rt *Route) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		size, types := rt.bodyLimits()
+		hasBody := req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0
+
+		if len(types) > 0 && hasBody && !acceptsType(types, req.Header.Get("Content-Type")) {
+			http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if size > 0 && hasBody {
+			if req.ContentLength > size {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if req.ContentLength < 0 {
+				body := &limitBody{ReadCloser: http.MaxBytesReader(w, req.Body, size)}
+				lw := &limitWriter{ResponseWriter: w, body: body}
+				req.Body = body
+
+				next.ServeHTTP(exposeWrapped(lw), req)
+				lw.finish()
+				return
+			}
+			req.Body = http.MaxBytesReader(w, req.Body, size)
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// acceptsType reports whether the Content-Type header media type is covered by any of the (possibly wildcard) media ranges.
+func acceptsType(types []string, ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return false
+	}
+
+	for _, t := range types {
+		if mediaTypeMatch(t, mt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// limitBody flags reads going over the body size limit.
+type limitBody struct {
+	io.ReadCloser
+
+	exceeded atomic.Bool
+}
+
+// Read implements io.Reader
+func (b *limitBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+
+	var maxErr *http.MaxBytesError
+	if err != nil && errors.As(err, &maxErr) {
+		b.exceeded.Store(true)
+	}
+
+	return n, err
+}
+
+// limitWriter answers with 413 Request Entity Too Large, instead of the handler response,
+// if the body went over the limit before the handler started writing it.
+type limitWriter struct {
+	http.ResponseWriter
+
+	body *limitBody
+
+	// Whether the response was started, and whether it's the 413 one
+	wroteHeader bool
+	tooLarge    bool
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *limitWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if w.body.exceeded.Load() {
+		w.tooLarge = true
+		http.Error(w.ResponseWriter, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter. Writes are discarded once the response is the 413 one.
+func (w *limitWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.tooLarge {
+		return len(b), nil
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// finish sends the 413 response if the handler went over the limit without writing a response.
+func (w *limitWriter) finish() {
+	if !w.wroteHeader && w.body.exceeded.Load() {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}
+}
+
+// Flush implements http.Flusher
+func (w *limitWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// hijacked implements wrappedWriter: the response can't be replaced anymore.
+func (w *limitWriter) hijacked() {
+	w.wroteHeader = true
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (w *limitWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,120 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimits(t *testing.T) {
+	readBody := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+		}
+	}
+
+	r := New("/")
+	r.MaxBodySize(10)
+	r.Accepts("application/json")
+	r.Add("/users", http.HandlerFunc(readBody)).Methods("POST")
+	r.Add("/ignore", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Handlers ignoring the read error still get 413
+		io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	})).Methods("POST")
+	r.Add("/avatars", http.HandlerFunc(readBody)).Methods("POST").MaxBodySize(100).Accepts("image/*")
+	r.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	d := Build(r)
+
+	for _, c := range []struct {
+		method, path, contentType, body string
+		chunked                         bool
+		code                            int
+	}{
+		{"POST", "/users", "application/json", `{"a":1}`, false, http.StatusOK},
+		{"POST", "/users", "application/json; charset=utf-8", `{"a":1}`, false, http.StatusOK},
+		{"POST", "/users", "text/plain", `{"a":1}`, false, http.StatusUnsupportedMediaType},
+		{"POST", "/users", "", `{"a":1}`, false, http.StatusUnsupportedMediaType},
+		{"POST", "/users", "application/json", `{"name":"joe"}`, false, http.StatusRequestEntityTooLarge},
+		{"POST", "/users", "application/json", `{"name":"joe"}`, true, http.StatusRequestEntityTooLarge},
+		{"POST", "/users", "application/json", `{"a":1}`, true, http.StatusOK},
+		{"POST", "/ignore", "application/json", `{"name":"joe"}`, true, http.StatusRequestEntityTooLarge},
+		{"POST", "/ignore", "application/json", `{"a":1}`, true, http.StatusCreated},
+		{"POST", "/users", "", "", false, http.StatusOK},
+		{"POST", "/avatars", "image/png", strings.Repeat("a", 50), false, http.StatusOK},
+		{"POST", "/avatars", "application/json", `{"a":1}`, false, http.StatusUnsupportedMediaType},
+		{"PUT", "/users", "text/plain", `{"a":1}`, false, http.StatusMethodNotAllowed},
+	} {
+		var body io.Reader = strings.NewReader(c.body)
+		if c.chunked {
+			// Hide the length
+			body = io.MultiReader(body)
+		}
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(c.method, c.path, body)
+		if c.chunked {
+			req.ContentLength = -1
+		}
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		d.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("%s %s %s '%s' should respond %d. Got %d", c.method, c.path, c.contentType, c.body, c.code, w.Code)
+		}
+		if (c.code == http.StatusUnsupportedMediaType) && w.Header().Get("X-Middleware") != "" {
+			t.Errorf("%s %s %s should be rejected before the router middleware", c.method, c.path, c.contentType)
+		}
+	}
+}
+
+func TestBodyLimitsInfo(t *testing.T) {
+	var info *RouteInfo
+
+	r := New("/")
+	r.MaxBodySize(10)
+	r.Accepts("application/json")
+	r.Add("/ignore", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Handlers ignoring the read error still get 413
+		io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	})).Methods("POST")
+	r.Add("/avatars", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = RouteFromContext(r.Context())
+	})).Accepts("image/*")
+
+	Build(r).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/avatars", nil))
+
+	if info == nil || info.MaxBodySize != 10 || len(info.Accepts) != 1 || info.Accepts[0] != "image/*" {
+		t.Errorf("Route info should expose the body limits. Got %+v", info)
+	}
+}
+
+func TestBodyLimitsHijacker(t *testing.T) {
+	r := New("/")
+	r.MaxBodySize(10)
+
+	testHijacker(t, func(h http.Handler) http.Handler {
+		r.Add("/", h)
+		return Build(r)
+	}, func() *http.Request {
+		req := httptest.NewRequest("POST", "/", io.MultiReader(strings.NewReader("{}")))
+		req.ContentLength = -1
+		return req
+	})
+}
@@ -0,0 +1,197 @@
+package router
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Matcher checks request attributes other than the path.
+// Matchers can be attached to routes with Route.When and to routers with Router.When.
+type Matcher interface {
+	// Match reports whether the request satisfies the matcher.
+	Match(*http.Request) bool
+}
+
+// MatcherFunc is an adapter to use ordinary functions as Matcher.
+type MatcherFunc func(*http.Request) bool
+
+// Match calls f(r).
+func (f MatcherFunc) Match(r *http.Request) bool {
+	return f(r)
+}
+
+// matchAll reports whether the request satisfies all matchers.
+func matchAll(matchers []Matcher, r *http.Request) bool {
+	for _, m := range matchers {
+		if !m.Match(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Header matches requests having the header key set to value.
+// If value is empty, it only checks for the header presence.
+func Header(key, value string) Matcher {
+	return MatcherFunc(func(r *http.Request) bool {
+		values, ok := r.Header[http.CanonicalHeaderKey(key)]
+		if !ok {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Query matches requests having the query parameter key set to value.
+// If value is empty, it only checks for the parameter presence.
+func Query(key, value string) Matcher {
+	return MatcherFunc(func(r *http.Request) bool {
+		values, ok := r.URL.Query()[key]
+		if !ok {
+			return false
+		}
+		if value == "" {
+			return true
+		}
+		for _, v := range values {
+			if v == value {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+// Scheme matches requests made over the given scheme ("http" or "https").
+// The scheme is taken from the request URL if absolute, otherwise from the connection TLS state.
+func Scheme(scheme string) Matcher {
+	scheme = strings.ToLower(scheme)
+
+	return MatcherFunc(func(r *http.Request) bool {
+		return requestScheme(r) == scheme
+	})
+}
+
+// requestScheme returns the scheme used by the request.
+func requestScheme(r *http.Request) string {
+	if r.URL != nil && r.URL.Scheme != "" {
+		return strings.ToLower(r.URL.Scheme)
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// ContentType matches requests whose Content-Type header has the given media type.
+// Media type parameters such as charset are ignored.
+func ContentType(mediaType string) Matcher {
+	mediaType = strings.ToLower(mediaType)
+
+	return MatcherFunc(func(r *http.Request) bool {
+		mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			return false
+		}
+
+		return mt == mediaType
+	})
+}
+
+// Accept matches requests whose Accept header accepts the given media type,
+// honouring wildcards ("*/*", "type/*") and rejecting types with q=0.
+// As in RFC 9110 12.5.1, the most specific range covering the type decides,
+// so "application/json;q=0, */*" doesn't accept "application/json".
+// Requests without an Accept header accept anything.
+func Accept(mediaType string) Matcher {
+	mediaType = strings.ToLower(mediaType)
+
+	return MatcherFunc(func(r *http.Request) bool {
+		accept := r.Header.Get("Accept")
+		if accept == "" {
+			return true
+		}
+
+		q, ok := acceptQuality(parseAccept(accept), mediaType)
+		return ok && q > 0
+	})
+}
+
+// acceptValue is a single media range from an Accept header.
+type acceptValue struct {
+	value  string
+	params map[string]string
+	q      float64
+}
+
+// parseAccept parses the media ranges of an Accept header.
+func parseAccept(header string) []acceptValue {
+	values := make([]acceptValue, 0)
+	for _, part := range strings.Split(header, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		av := acceptValue{value: mt, params: params, q: 1}
+		if q, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(q, 64); err == nil {
+				av.q = f
+			}
+		}
+
+		values = append(values, av)
+	}
+
+	return values
+}
+
+// acceptQuality returns the q-value the most specific of the media ranges covering the media type gives it:
+// the type itself, then "type/*", then "*/*". It returns false if no range covers it.
+func acceptQuality(values []acceptValue, mediaType string) (float64, bool) {
+	q, best := 0.0, -1
+	for _, av := range values {
+		if !mediaTypeMatch(av.value, mediaType) {
+			continue
+		}
+
+		specificity := 2
+		switch {
+		case av.value == "*/*":
+			specificity = 0
+		case strings.HasSuffix(av.value, "/*"):
+			specificity = 1
+		}
+		if specificity > best {
+			q, best = av.q, specificity
+		}
+	}
+
+	return q, best >= 0
+}
+
+// mediaTypeMatch reports whether the media type is covered by the (possibly wildcard) media range.
+func mediaTypeMatch(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+	if strings.HasSuffix(mediaRange, "/*") {
+		return strings.HasPrefix(mediaType, mediaRange[:len(mediaRange)-1])
+	}
+
+	return false
+}
@@ -0,0 +1,168 @@
+package router
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHeaderMatcher(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Version", "2")
+
+	if !Header("X-API-Version", "2").Match(req) {
+		t.Error("Header matcher should match X-API-Version: 2")
+	}
+	if !Header("x-api-version", "").Match(req) {
+		t.Error("Header matcher should match header presence")
+	}
+	if Header("X-API-Version", "1").Match(req) {
+		t.Error("Header matcher shouldn't match X-API-Version: 1")
+	}
+	if Header("X-Other", "").Match(req) {
+		t.Error("Header matcher shouldn't match missing header")
+	}
+}
+
+func TestQueryMatcher(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?debug&format=json", nil)
+
+	if !Query("debug", "").Match(req) {
+		t.Error("Query matcher should match parameter presence")
+	}
+	if !Query("format", "json").Match(req) {
+		t.Error("Query matcher should match format=json")
+	}
+	if Query("format", "xml").Match(req) {
+		t.Error("Query matcher shouldn't match format=xml")
+	}
+}
+
+func TestSchemeMatcher(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if !Scheme("http").Match(req) {
+		t.Error("Scheme matcher should match plain http request")
+	}
+
+	req.TLS = &tls.ConnectionState{}
+	if !Scheme("HTTPS").Match(req) {
+		t.Error("Scheme matcher should match TLS request")
+	}
+}
+
+func TestContentTypeMatcher(t *testing.T) {
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	if !ContentType("application/json").Match(req) {
+		t.Error("ContentType matcher should ignore media type parameters")
+	}
+	if ContentType("text/plain").Match(req) {
+		t.Error("ContentType matcher shouldn't match text/plain")
+	}
+}
+
+func TestAcceptMatcher(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	if !Accept("application/json").Match(req) {
+		t.Error("Accept matcher should match requests without Accept header")
+	}
+
+	req.Header.Set("Accept", "text/*, application/xml;q=0")
+	if !Accept("text/html").Match(req) {
+		t.Error("Accept matcher should match text/* range")
+	}
+	if Accept("application/xml").Match(req) {
+		t.Error("Accept matcher shouldn't match types with q=0")
+	}
+	if Accept("application/json").Match(req) {
+		t.Error("Accept matcher shouldn't match types not accepted")
+	}
+
+	// The most specific range decides
+	req.Header.Set("Accept", "application/json;q=0, text/*;q=0, text/html, */*")
+	if Accept("application/json").Match(req) {
+		t.Error("Accept matcher shouldn't match types refused explicitly, even if */* accepts them")
+	}
+	if Accept("text/plain").Match(req) {
+		t.Error("Accept matcher shouldn't match types refused by their type range")
+	}
+	if !Accept("text/html").Match(req) || !Accept("image/png").Match(req) {
+		t.Error("Accept matcher should match types accepted by their most specific range")
+	}
+}
+
+func TestRouteMatchers(t *testing.T) {
+	r := New("/")
+	r.Add("/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v2"))
+	})).When(Header("X-API-Version", "2"))
+	r.Add("/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v1"))
+	}))
+	r.Add("/:name", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("json " + Param(r, "name")))
+	})).When(Accept("application/json"))
+	r.Add("/secure", http.HandlerFunc(handler)).When(Scheme("https"))
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("X-API-Version", "2")
+	d.ServeHTTP(w, req)
+	if w.Body.String() != "v2" {
+		t.Errorf("Response body should be 'v2'. Got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/items", nil))
+	if w.Body.String() != "v1" {
+		t.Errorf("Response body should be 'v1'. Got %s", w.Body.String())
+	}
+
+	// Static /secure route requires https, the param route takes it
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("GET", "/secure", nil)
+	req.Header.Set("Accept", "application/json")
+	d.ServeHTTP(w, req)
+	if w.Body.String() != "json secure" {
+		t.Errorf("Response body should be 'json secure'. Got %s", w.Body.String())
+	}
+
+	req = httptest.NewRequest("GET", "/secure", nil)
+	req.Header.Set("Accept", "text/html")
+	if h := r.Match(req); h != nil {
+		t.Error("/secure shouldn't match over http with an Accept header other than JSON")
+	}
+}
+
+func TestRouterMatchers(t *testing.T) {
+	r1 := New("/")
+	r1.When(Header("X-API-Version", "2"))
+	r1.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v2"))
+	}))
+
+	r2 := New("/")
+	r2.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("v1"))
+	}))
+
+	d := Build(r1, r2)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Body.String() != "v1" {
+		t.Errorf("Response body should be 'v1'. Got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-API-Version", "2")
+	d.ServeHTTP(w, req)
+	if w.Body.String() != "v2" {
+		t.Errorf("Response body should be 'v2'. Got %s", w.Body.String())
+	}
+}
@@ -0,0 +1,256 @@
+package router
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are the default latency histogram buckets, in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// sizeBuckets are the response size histogram buckets, in bytes.
+var sizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7, 1e8}
+
+// Metrics collects request metrics labelled by method, route pattern and status class,
+// and exposes them in the Prometheus text exposition format.
+//
+// Metrics.Middleware records the metrics and Metrics itself is the http.Handler serving them:
+//
+//	m := router.NewMetrics("myapp", nil)
+//	r.Add("/metrics", m)
+//	d.Wrap(m.Middleware)
+//
+// Routes are labelled by pattern instead of raw path to keep cardinality bounded.
+type Metrics struct {
+	// Metric names prefix
+	namespace string
+
+	// Latency histogram buckets
+	buckets []float64
+
+	mu       sync.Mutex
+	requests map[metricLabels]*requestMetrics
+	inFlight map[metricLabels]int64
+}
+
+// metricLabels identifies a metrics series.
+type metricLabels struct {
+	method string
+	route  string
+	status string
+}
+
+// requestMetrics holds the metrics of a single series.
+type requestMetrics struct {
+	latency histogram
+	size    histogram
+}
+
+// histogram is a Prometheus style histogram. counts aren't cumulative.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// observe adds v to the histogram.
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, b := range buckets {
+		if v <= b {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// NewMetrics creates a Metrics collector.
+// Metric names are prefixed by namespace, if not empty.
+// If buckets is nil, DefBuckets are used for latency histograms.
+func NewMetrics(namespace string, buckets []float64) *Metrics {
+	if buckets == nil {
+		buckets = DefBuckets
+	}
+	if namespace != "" {
+		namespace += "_"
+	}
+
+	b := append([]float64{}, buckets...)
+	sort.Float64s(b)
+
+	return &Metrics{
+		namespace: namespace,
+		buckets:   b,
+		requests:  make(map[metricLabels]*requestMetrics),
+		inFlight:  make(map[metricLabels]int64),
+	}
+}
+
+// Middleware records the metrics for every request.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		w := wrapWriter(rw)
+		labels := metricLabels{method: metricMethod(req.Method), route: Pattern(req)}
+
+		m.mu.Lock()
+		m.inFlight[labels]++
+		m.mu.Unlock()
+
+		defer func() {
+			// Count panicking requests as 500 before passing the panic on
+			v := recover()
+
+			status := w.status
+			if status == 0 {
+				status = http.StatusOK
+				if v != nil {
+					status = http.StatusInternalServerError
+				}
+			}
+
+			series := labels
+			series.status = strconv.Itoa(status/100) + "xx"
+
+			m.mu.Lock()
+			m.inFlight[labels]--
+
+			rm, ok := m.requests[series]
+			if !ok {
+				rm = &requestMetrics{}
+				m.requests[series] = rm
+			}
+			rm.latency.observe(m.buckets, time.Since(start).Seconds())
+			rm.size.observe(sizeBuckets, float64(w.size))
+			m.mu.Unlock()
+
+			if v != nil {
+				panic(v)
+			}
+		}()
+
+		next.ServeHTTP(w.writer(), req)
+	})
+}
+
+// metricMethod returns the method label, mapping non-standard methods to "OTHER" to keep cardinality bounded.
+func metricMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+
+	return "OTHER"
+}
+
+// ServeHTTP implements http.Handler, writing the metrics in Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in Prometheus text exposition format to w.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	buf := new(strings.Builder)
+
+	series := make([]metricLabels, 0, len(m.requests))
+	for l := range m.requests {
+		series = append(series, l)
+	}
+	sortLabels(series)
+
+	// Requests count
+	name := m.namespace + "http_requests_total"
+	fmt.Fprintf(buf, "# HELP %s Total number of HTTP requests.\n# TYPE %s counter\n", name, name)
+	for _, l := range series {
+		fmt.Fprintf(buf, "%s{%s} %d\n", name, l.format(), m.requests[l].latency.count)
+	}
+
+	// Histograms
+	name = m.namespace + "http_request_duration_seconds"
+	fmt.Fprintf(buf, "# HELP %s HTTP request latency in seconds.\n# TYPE %s histogram\n", name, name)
+	for _, l := range series {
+		writeHistogram(buf, name, l.format(), m.buckets, &m.requests[l].latency)
+	}
+
+	name = m.namespace + "http_response_size_bytes"
+	fmt.Fprintf(buf, "# HELP %s HTTP response size in bytes.\n# TYPE %s histogram\n", name, name)
+	for _, l := range series {
+		writeHistogram(buf, name, l.format(), sizeBuckets, &m.requests[l].size)
+	}
+
+	// In flight
+	inFlight := make([]metricLabels, 0, len(m.inFlight))
+	for l := range m.inFlight {
+		inFlight = append(inFlight, l)
+	}
+	sortLabels(inFlight)
+
+	name = m.namespace + "http_requests_in_flight"
+	fmt.Fprintf(buf, "# HELP %s Number of HTTP requests being served.\n# TYPE %s gauge\n", name, name)
+	for _, l := range inFlight {
+		fmt.Fprintf(buf, "%s{%s} %d\n", name, l.format(), m.inFlight[l])
+	}
+
+	n, err := io.WriteString(w, buf.String())
+
+	return int64(n), err
+}
+
+// writeHistogram writes the bucket, sum and count series of a histogram.
+func writeHistogram(w io.Writer, name, labels string, buckets []float64, h *histogram) {
+	var cumulative uint64
+	for i, b := range buckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(b, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// format returns the labels in exposition format, skipping an empty status.
+func (l metricLabels) format() string {
+	s := `method="` + escapeLabel(l.method) + `",route="` + escapeLabel(l.route) + `"`
+	if l.status != "" {
+		s += `,status="` + l.status + `"`
+	}
+
+	return s
+}
+
+// escapeLabel escapes a label value as required by the exposition format.
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// sortLabels sorts series by route, method and status so the output is stable.
+func sortLabels(series []metricLabels) {
+	sort.Slice(series, func(i, j int) bool {
+		a, b := series[i], series[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+
+		return a.status < b.status
+	})
+}
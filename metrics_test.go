@@ -0,0 +1,70 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics("test", []float64{1, 0.5})
+
+	r := New("/")
+	r.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch Param(r, "id") {
+		case "0":
+			w.WriteHeader(http.StatusNotFound)
+		case "panic":
+			panic("boom")
+		}
+		w.Write([]byte("Hello"))
+	}))
+	r.Add("/metrics", m)
+
+	d := Build(r)
+	d.Wrap(m.Middleware)
+
+	d.Recover(nil, false)
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/0", "/users/panic"} {
+		d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/users/1", nil))
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type isn't the Prometheus text format. Got %s", w.Header().Get("Content-Type"))
+	}
+
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE test_http_requests_total counter",
+		`test_http_requests_total{method="GET",route="/users/:id",status="2xx"} 2`,
+		`test_http_requests_total{method="GET",route="/users/:id",status="4xx"} 1`,
+		`test_http_requests_total{method="GET",route="/users/:id",status="5xx"} 1`,
+		`test_http_requests_total{method="OTHER",route="/users/:id",status="2xx"} 1`,
+		"# TYPE test_http_request_duration_seconds histogram",
+		`test_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="0.5"} 2`,
+		`test_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="1"} 2`,
+		`test_http_request_duration_seconds_bucket{method="GET",route="/users/:id",status="2xx",le="+Inf"} 2`,
+		`test_http_request_duration_seconds_count{method="GET",route="/users/:id",status="2xx"} 2`,
+		`test_http_response_size_bytes_bucket{method="GET",route="/users/:id",status="2xx",le="100"} 2`,
+		`test_http_response_size_bytes_sum{method="GET",route="/users/:id",status="2xx"} 10`,
+		"# TYPE test_http_requests_in_flight gauge",
+		`test_http_requests_in_flight{method="GET",route="/users/:id"} 0`,
+		`test_http_requests_in_flight{method="GET",route="/metrics"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Metrics output should contain '%s'. Got:\n%s", line, body)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if v := escapeLabel("a\"b\\c\nd"); v != `a\"b\\c\nd` {
+		t.Errorf("Label value isn't escaped as expected. Got %s", v)
+	}
+}
@@ -1,7 +1,6 @@
 package router
 
 import (
-	"context"
 	"net/http"
 	"path"
 	"path/filepath"
@@ -11,7 +10,7 @@ import (
 // node represents each path part in a route and constructs a tree
 type node struct {
 	path     string
-	handler  http.Handler
+	routes   []*Route
 	parent   *node
 	children []*node
 }
@@ -23,18 +22,19 @@ func rootNode(route string, handler http.Handler) *node {
 		children: make([]*node, 0),
 	}
 
-	n.add(route, handler)
+	if handler != nil {
+		n.add(route, handler)
+	}
 
 	return n
 }
 
 // add constructs the children tree for the current node matching the route provided.
-// It sets the http.Handler to the final element.
-func (n *node) add(route string, handler http.Handler) {
+// It adds a Route for the http.Handler to the final element and returns it.
+func (n *node) add(route string, handler http.Handler) *Route {
 	// Root and matches
 	if route == n.path || n.path == "*" {
-		n.handler = handler
-		return
+		return n.addRoute(handler)
 	}
 
 	// Remove starting and trailing "/"
@@ -48,28 +48,75 @@ func (n *node) add(route string, handler http.Handler) {
 	// Lookup as far as possible
 	nn, remain := n.walk(strings.Split(route, "/"))
 
-	// Add pending parts if any and stop adding after catch-all
-	if len(remain) > 0 {
-		if nn.path != "*" {
-			// Create child
-			ch := &node{
-				path:     remain[0],
-				children: make([]*node, 0),
-				parent:   nn,
-			}
+	// Existing node or catch-all
+	if len(remain) == 0 || nn.path == "*" {
+		return nn.addRoute(handler)
+	}
 
-			// Go deeper
-			if len(remain) > 1 {
-				ch.add(strings.Join(remain[1:], "/"), handler)
-			} else {
-				ch.handler = handler
-			}
+	// Create child
+	ch := &node{
+		path:     remain[0],
+		children: make([]*node, 0),
+		parent:   nn,
+	}
+
+	// Save route
+	nn.insert(ch)
+
+	// Go deeper
+	if len(remain) > 1 {
+		return ch.add(strings.Join(remain[1:], "/"), handler)
+	}
+
+	return ch.addRoute(handler)
+}
+
+// insert adds a child keeping the children ranked by specificity: static parts, then params, then the catch-all,
+// so matching tries the most specific candidates first. Equally specific children keep insertion order.
+func (n *node) insert(ch *node) {
+	i := len(n.children)
+	for i > 0 && n.children[i-1].rank() < ch.rank() {
+		i--
+	}
+
+	n.children = append(n.children, nil)
+	copy(n.children[i+1:], n.children[i:])
+	n.children[i] = ch
+}
 
-			// Save route
-			nn.children = append(nn.children, ch)
-			return
+// rank returns the specificity of the node path part.
+func (n *node) rank() int {
+	switch {
+	case n.path == "*":
+		return rankWildcard
+	case n.path[0] == ':':
+		return rankParam
+	}
+
+	return rankStatic
+}
+
+// addRoute appends a new Route for the handler to the current node.
+func (n *node) addRoute(handler http.Handler) *Route {
+	rt := &Route{
+		handler: handler,
+		node:    n,
+		pattern: n.buildPath(),
+	}
+	n.routes = append(n.routes, rt)
+
+	return rt
+}
+
+// pick returns the first route in the current node matching the request, if any.
+func (n *node) pick(r *http.Request, c compare) *Route {
+	for _, rt := range n.routes {
+		if rt.matches(r, c.anyMethod) {
+			return rt
 		}
 	}
+
+	return nil
 }
 
 // walk moves through nodes for a given path until no further match is found.
@@ -90,40 +137,52 @@ func (n *node) walk(parts []string) (*node, []string) {
 	return n, parts
 }
 
+// compare defines how static route parts are matched against request path parts.
+type compare struct {
+	// anyMethod skips the routes methods check, to find the methods allowed for a path
+	anyMethod bool
+
+	// fold enables case-insensitive comparison
+	fold bool
+
+	// normalize is applied to both parts before comparing, if set
+	normalize func(string) string
+}
+
+// equal reports whether the request path part matches the static route part.
+func (c compare) equal(route, part string) bool {
+	if c.normalize != nil {
+		route, part = c.normalize(route), c.normalize(part)
+	}
+	if c.fold {
+		return strings.EqualFold(route, part)
+	}
+
+	return route == part
+}
+
 // match searches for a matching route to the current request.
-// If found, it adds the route params to the request context and return the corresponding handler.
-func (n *node) match(r *http.Request) http.Handler {
+// If found, it fills the params map with the route params and returns the corresponding route.
+func (n *node) match(r *http.Request, params map[string]string, c compare) *Route {
 	// Validate root node match
 	if n.path != "/" {
 		return nil
 	}
 
 	if r.URL.Path == "/" || r.URL.Path == "" {
-		return n.handler
+		return n.pick(r, c)
 	}
 
-	// Create parameters storage
-	params := make(map[string]string)
-
 	// Cleanup path
 	r.URL.Path = filepath.Clean(r.URL.Path)
 
-	// Get handler
-	h := n.matchChild(r.URL.Path[1:], r, params)
-
-	// Set params if needed
-	if h != nil && len(params) > 0 {
-		*r = *r.WithContext(context.WithValue(
-			r.Context(),
-			routeParamsKey{},
-			params))
-	}
-
-	return h
+	// Get route
+	return n.matchChild(r.URL.Path[1:], r, params, c)
 }
 
 // matchChild does the recursive work of matching the tree parts and try to find the correct path for a route.
-func (n *node) matchChild(part string, r *http.Request, params map[string]string) http.Handler {
+// Routes whose matchers fail are skipped so the search falls through to the next candidate.
+func (n *node) matchChild(part string, r *http.Request, params map[string]string, c compare) *Route {
 	// Invalid route parts
 	if part == "" {
 		return nil
@@ -131,7 +190,7 @@ func (n *node) matchChild(part string, r *http.Request, params map[string]string
 
 	// Remove trailing slashes
 	for len(part) > 0 && part[len(part)-1] == '/' {
-		n.matchChild(part[:len(part)-1], r, params)
+		n.matchChild(part[:len(part)-1], r, params, c)
 	}
 
 	// Split parts
@@ -147,33 +206,38 @@ func (n *node) matchChild(part string, r *http.Request, params map[string]string
 				// Are we done?
 				if len(part) == (i + 1) {
 					// Set last param and return
-					params[ch.path[1:]] = part[:i+1]
-					return ch.handler
-				}
-
-				// Set param
-				params[ch.path[1:]] = part[:i]
-
-				// Go deeper
-				h := ch.matchChild(part[i+1:], r, params)
-				if h != nil {
-					return h
+					if rt := ch.pick(r, c); rt != nil {
+						params[ch.path[1:]] = part[:i+1]
+						return rt
+					}
+				} else {
+					// Set param
+					params[ch.path[1:]] = part[:i]
+
+					// Go deeper
+					if rt := ch.matchChild(part[i+1:], r, params, c); rt != nil {
+						return rt
+					}
+
+					// Undo param on dead ends
+					delete(params, ch.path[1:])
 				}
 			}
 
 			// Last route part
 			if len(part) == (i + 1) {
-				if part[:i+1] == ch.path {
-					return ch.handler
+				if c.equal(ch.path, part[:i+1]) {
+					if rt := ch.pick(r, c); rt != nil {
+						return rt
+					}
 				}
 			}
 
 			// Match current
-			if part[:i] == ch.path {
+			if c.equal(ch.path, part[:i]) {
 				// Go deeper
-				h := ch.matchChild(part[i+1:], r, params)
-				if h != nil {
-					return h
+				if rt := ch.matchChild(part[i+1:], r, params, c); rt != nil {
+					return rt
 				}
 			}
 		}
@@ -181,7 +245,9 @@ func (n *node) matchChild(part string, r *http.Request, params map[string]string
 		// Check for catch-all routes.
 		for _, ch := range n.children {
 			if ch.path == "*" {
-				return ch.handler
+				if rt := ch.pick(r, c); rt != nil {
+					return rt
+				}
 			}
 		}
 
@@ -202,3 +268,44 @@ func (n *node) buildPath() string {
 
 	return path.Join(n.parent.buildPath(), n.path)
 }
+
+// parts returns the path parts from the root to the current node, excluding the root.
+func (n *node) parts() []string {
+	parts := make([]string, 0)
+	for ; n != nil && n.parent != nil; n = n.parent {
+		parts = append([]string{n.path}, parts...)
+	}
+
+	return parts
+}
+
+// allRoutes returns the routes stored in the current node and all its children, depth first.
+func (n *node) allRoutes() []*Route {
+	routes := append([]*Route{}, n.routes...)
+	for _, ch := range n.children {
+		routes = append(routes, ch.allRoutes()...)
+	}
+
+	return routes
+}
+
+// methods returns the methods allowed by the routes in the current node matching the request, ignoring their methods.
+// It's nil if any of them allows all methods.
+func (n *node) methods(r *http.Request) []string {
+	methods := make([]string, 0)
+	for _, rt := range n.routes {
+		if !rt.matches(r, true) {
+			continue
+		}
+		if len(rt.methods) == 0 {
+			return nil
+		}
+		for _, m := range rt.methods {
+			if !contains(methods, m) {
+				methods = append(methods, m)
+			}
+		}
+	}
+
+	return methods
+}
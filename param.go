@@ -1,11 +1,24 @@
 package router
 
 import (
+	"context"
 	"net/http"
 )
 
 type routeParamsKey struct{}
 
+// setParams stores the route params in the request context, if any.
+func setParams(r *http.Request, params map[string]string) {
+	if len(params) == 0 {
+		return
+	}
+
+	*r = *r.WithContext(context.WithValue(
+		r.Context(),
+		routeParamsKey{},
+		params))
+}
+
 // Params returns a map[string]string containing all route parameters
 func Params(req *http.Request) map[string]string {
 	params := req.Context().Value(routeParamsKey{})
@@ -0,0 +1,248 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Route parts specificity, from lowest to highest.
+const (
+	rankWildcard = iota
+	rankParam
+	rankStatic
+)
+
+// rank returns the specificity of each part of the route path, from the root.
+func (rt *Route) rank() []int {
+	ranks := make([]int, 0)
+	for n := rt.node; n != nil && n.parent != nil; n = n.parent {
+		ranks = append(ranks, n.rank())
+	}
+
+	// Reverse
+	for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+		ranks[i], ranks[j] = ranks[j], ranks[i]
+	}
+
+	return ranks
+}
+
+// moreSpecific reports whether route a is more specific than route b.
+// Parts are compared from the root: static beats param and param beats wildcard.
+// If all common parts are equal, the longest route wins.
+func moreSpecific(a, b *Route) bool {
+	ra, rb := a.rank(), b.rank()
+	for i := 0; i < len(ra) && i < len(rb); i++ {
+		if ra[i] != rb[i] {
+			return ra[i] > rb[i]
+		}
+	}
+
+	return len(ra) > len(rb)
+}
+
+// matchRouters finds the best router matching the request and returns its handler.
+// Routers are ranked by priority first and route specificity next,
+// ties are resolved in insertion order.
+// If the path matches routes restricted to other methods, the 405 / OPTIONS handler of the best ranked of them is returned
+// when nothing else matched, or when it's more specific than the route matched (e.g. a catch-all).
+// If guard isn't nil, it wraps the handler and each router middleware layer.
+func matchRouters(routes []Router, req *http.Request, guard Middleware) http.Handler {
+	var (
+		best   *router
+		route  *Route
+		params map[string]string
+	)
+
+	for _, r := range routes {
+		rr := r.impl()
+
+		rt, p := rr.lookup(req)
+		if rt == nil {
+			continue
+		}
+
+		if best == nil || outranks(rr, rt, best, route) {
+			best, route, params = rr, rt, p
+		}
+	}
+
+	if best != nil {
+		// Only routes with params or wildcards can be outranked by routes for other methods
+		if !route.static() {
+			if rr, rt, p := matchMethods(routes, req, best, route); rt != nil {
+				return rr.handle(req, rt, p, guard)
+			}
+		}
+
+		return best.handle(req, route, params, guard)
+	}
+
+	// Paths matching routes for other methods
+	if rr, rt, p := matchMethods(routes, req, nil, nil); rt != nil {
+		return rr.handle(req, rt, p, guard)
+	}
+
+	return nil
+}
+
+// matchMethods finds the best ranked router whose routes match the request path for other methods.
+// If best isn't nil, the candidate router must have at least its priority and the candidate route must be more specific than route,
+// e.g. a path restricted to POST in the same router as a catch-all.
+func matchMethods(routes []Router, req *http.Request, best *router, route *Route) (*router, *Route, map[string]string) {
+	var (
+		found  *router
+		rt     *Route
+		params map[string]string
+	)
+
+	for _, r := range routes {
+		rr := r.impl()
+
+		m, p := rr.lookupMethods(req)
+		// Routes for other methods don't take requests from a route allowing the method
+		// unless they are more specific and their router priority isn't lower.
+		if m == nil || (best != nil && (rr.priority < best.priority || !moreSpecific(m, route))) {
+			continue
+		}
+
+		if found == nil || outranks(rr, m, found, rt) {
+			found, rt, params = rr, m, p
+		}
+	}
+
+	return found, rt, params
+}
+
+// outranks reports whether route a from router ra is preferred over route b from router rb.
+func outranks(ra *router, a *Route, rb *router, b *Route) bool {
+	return ra.priority > rb.priority || (ra.priority == rb.priority && moreSpecific(a, b))
+}
+
+// static reports whether all the route parts are static.
+func (rt *Route) static() bool {
+	for _, r := range rt.rank() {
+		if r != rankStatic {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unreachable lists the routes in the routers that can never be matched
+// because an earlier ranked route takes all their requests: one with the same path shape, or covering their paths from a router with higher priority.
+func unreachable(routes []Router) []string {
+	type entry struct {
+		router *router
+		route  *Route
+	}
+
+	entries := make([]entry, 0)
+	for _, r := range routes {
+		rr := r.impl()
+		for _, rt := range rr.tree.allRoutes() {
+			entries = append(entries, entry{router: rr, route: rt})
+		}
+	}
+
+	// Resolution order
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].router.priority > entries[j].router.priority
+	})
+
+	found := make([]string, 0)
+	for i, e := range entries {
+		for _, prev := range entries[:i] {
+			if shadows(prev.router, prev.route, e.router, e.route) {
+				found = append(found, e.route.pattern+" is shadowed by "+prev.route.pattern)
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+// shadows reports whether route a, tried before route b, takes every request b could match.
+func shadows(ra *router, a *Route, rb *router, b *Route) bool {
+	// Conditional routes let requests through
+	if len(a.matchers) > 0 || len(ra.matchers) > 0 {
+		return false
+	}
+	if ra.host != nil && (rb.host == nil || !sameHost(ra.host, rb.host)) {
+		return false
+	}
+
+	// Methods
+	if len(a.methods) > 0 {
+		if len(b.methods) == 0 {
+			return false
+		}
+		for _, m := range b.methods {
+			if !contains(a.methods, m) {
+				return false
+			}
+		}
+	}
+
+	// A higher priority router takes every path its route covers, however specific the other route is.
+	// On equal priority the most specific route wins, so only an earlier route with the same path shape takes them all.
+	if ra.priority > rb.priority {
+		return covers(a, b)
+	}
+
+	pa, pb := a.node, b.node
+	for pa != nil && pb != nil {
+		if pa.path != pb.path && !(pa.path[0] == ':' && pb.path[0] == ':') {
+			return false
+		}
+		pa, pb = pa.parent, pb.parent
+	}
+
+	return pa == nil && pb == nil
+}
+
+// covers reports whether every path matching route b also matches route a.
+// A catch-all part covers one or more parts, a param covers a single static or param part.
+func covers(a, b *Route) bool {
+	pa, pb := a.node.parts(), b.node.parts()
+	for i, p := range pa {
+		if p == "*" {
+			return i < len(pb)
+		}
+		if i >= len(pb) || pb[i] == "*" {
+			return false
+		}
+		if p[0] != ':' && p != pb[i] {
+			return false
+		}
+	}
+
+	return len(pa) == len(pb)
+}
+
+// sameHost reports whether two host patterns are equal.
+func sameHost(a, b *host) bool {
+	if len(a.labels) != len(b.labels) {
+		return false
+	}
+	for i := range a.labels {
+		if a.labels[i] != b.labels[i] && !(a.labels[i][0] == ':' && b.labels[i][0] == ':') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// contains reports whether s is in list.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,220 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func bodyHandler(body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	})
+}
+
+func TestDispatcherSpecificity(t *testing.T) {
+	catchAll := New("/")
+	catchAll.Add("/*", bodyHandler("catch-all"))
+
+	params := New("/users")
+	params.Add("/:id", bodyHandler("param"))
+
+	static := New("/users")
+	static.Add("/me", bodyHandler("static"))
+
+	d := Build(catchAll, params, static)
+
+	for path, body := range map[string]string{
+		"/users/me":   "static",
+		"/users/42":   "param",
+		"/other/path": "catch-all",
+	} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Body.String() != body {
+			t.Errorf("%s should be served by the %s route. Got %s", path, body, w.Body.String())
+		}
+	}
+}
+
+func TestRouterSpecificity(t *testing.T) {
+	r := New("/")
+	r.Add("/*", bodyHandler("catch-all"))
+	r.Add("/users/:id", bodyHandler("param"))
+	r.Add("/users/me", bodyHandler("static"))
+	r.Add("/users/:id/posts", bodyHandler("param-posts"))
+	r.Add("/users/me/posts", bodyHandler("static-posts"))
+
+	d := Build(r)
+
+	for path, body := range map[string]string{
+		"/users/me":       "static",
+		"/users/42":       "param",
+		"/users/me/posts": "static-posts",
+		"/users/42/posts": "param-posts",
+		"/other/path":     "catch-all",
+	} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Body.String() != body {
+			t.Errorf("%s should be served by the %s route. Got %s", path, body, w.Body.String())
+		}
+	}
+}
+
+func TestDispatcherLongestMatch(t *testing.T) {
+	short := New("/")
+	short.Add("/:a", bodyHandler("short"))
+
+	long := New("/")
+	long.Add("/:a/:b", bodyHandler("long"))
+	long.Add("/:a", bodyHandler("long-root"))
+
+	d := Build(short, long)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/x/y", nil))
+	if w.Body.String() != "long" {
+		t.Errorf("/x/y should be served by the long route. Got %s", w.Body.String())
+	}
+
+	// Equal specificity resolves by insertion order
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/x", nil))
+	if w.Body.String() != "short" {
+		t.Errorf("/x should be served by the first router. Got %s", w.Body.String())
+	}
+}
+
+func TestDispatcherPriority(t *testing.T) {
+	static := New("/")
+	static.Add("/users/me", bodyHandler("static"))
+
+	catchAll := New("/")
+	catchAll.Add("/*", bodyHandler("catch-all"))
+	catchAll.Priority(10)
+
+	d := Build(static, catchAll)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/users/me", nil))
+	if w.Body.String() != "catch-all" {
+		t.Errorf("Higher priority router should win. Got %s", w.Body.String())
+	}
+}
+
+func TestDispatcherMethodNotAllowed(t *testing.T) {
+	users := New("/")
+	users.Add("/users/:id", bodyHandler("users")).Methods("GET")
+
+	admin := New("/")
+	admin.Add("/users/:id", bodyHandler("admin")).Methods("DELETE")
+	admin.Priority(10)
+
+	catchAll := New("/")
+	catchAll.Add("/*", bodyHandler("catch-all"))
+
+	d := Build(users, admin, catchAll)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("PUT", "/users/1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Routes for other methods should win over the catch-all. Got %d %s", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Allow") != "DELETE, OPTIONS" {
+		t.Errorf("Allow header should come from the highest priority router. Got %s", w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	if w.Body.String() != "users" {
+		t.Errorf("Route allowing the method should win. Got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("PUT", "/other", nil))
+	if w.Body.String() != "catch-all" {
+		t.Errorf("Catch-all should serve other paths. Got %s", w.Body.String())
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	r1 := New("/")
+	r1.Add("/users/:id", bodyHandler("1"))
+	r1.Add("/items", bodyHandler("2")).Methods("GET")
+
+	r2 := New("/users")
+	r2.Add("/:name", bodyHandler("3"))
+	r2.Add("/:name", bodyHandler("4")).When(Header("X-Test", ""))
+
+	r3 := New("/")
+	r3.Add("/items", bodyHandler("5")).Methods("GET")
+	r3.Add("/items", bodyHandler("6")).Methods("POST")
+
+	d := Build(r1, r2, r3)
+
+	found := d.Unreachable()
+	expected := []string{
+		"/users/:name is shadowed by /users/:id",
+		"/users/:name is shadowed by /users/:id",
+		"/items is shadowed by /items",
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("Unreachable should have found %d routes. Got %v", len(expected), found)
+	}
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Errorf("Unreachable item %d should be '%s'. Got '%s'", i, expected[i], found[i])
+		}
+	}
+
+	// Priority changes the resolution order
+	r2.Priority(1)
+	if found := d.Unreachable(); len(found) != 3 || found[1] != "/users/:id is shadowed by /users/:name" {
+		t.Errorf("Unreachable should report /users/:id after raising r2 priority. Got %v", found)
+	}
+
+	// Higher priority routes shadow the more specific paths they cover
+	maintenance := New("/")
+	maintenance.Add("/*", bodyHandler("maintenance"))
+	maintenance.Priority(10)
+
+	api := New("/api")
+	api.Add("/users", bodyHandler("users"))
+	api.Add("/users/:id", bodyHandler("user"))
+
+	other := New("/")
+	other.Add("/", bodyHandler("root"))
+	other.Add("/:page", bodyHandler("page")).Methods("GET")
+
+	found = Build(maintenance, api, other).Unreachable()
+	expected = []string{
+		"/api/users is shadowed by /*",
+		"/api/users/:id is shadowed by /*",
+		"/:page is shadowed by /*",
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("Unreachable should have found %d routes. Got %v", len(expected), found)
+	}
+	for i := range expected {
+		if found[i] != expected[i] {
+			t.Errorf("Unreachable item %d should be '%s'. Got '%s'", i, expected[i], found[i])
+		}
+	}
+
+	// Params cover static parts when their router wins
+	users := New("/users")
+	users.Add("/:id", bodyHandler("user"))
+	users.Priority(1)
+
+	me := New("/users")
+	me.Add("/me", bodyHandler("me"))
+	me.Add("/me/posts", bodyHandler("posts"))
+
+	if found := Build(users, me).Unreachable(); len(found) != 1 || found[0] != "/users/me is shadowed by /users/:id" {
+		t.Errorf("Unreachable should report /users/me. Got %v", found)
+	}
+	if found := Build(me, New("/")).Unreachable(); len(found) != 0 {
+		t.Errorf("Unreachable shouldn't report routes of equal priority with different shapes. Got %v", found)
+	}
+}
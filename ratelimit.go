@@ -0,0 +1,215 @@
+package router
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limit defines a token bucket allowing Requests per Period, with bursts of up to Burst requests.
+// If Burst is 0, it's the same as Requests. A Limit with 0 Requests doesn't limit anything.
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// burst returns the bucket capacity.
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+
+	return l.Requests
+}
+
+// RateResult is the outcome of taking a token from a bucket.
+type RateResult struct {
+	// Allowed reports whether a token was available
+	Allowed bool
+
+	// Remaining tokens in the bucket
+	Remaining int
+
+	// Reset is the time until the bucket is full again
+	Reset time.Duration
+
+	// RetryAfter is the time until the next token is available, when not allowed
+	RetryAfter time.Duration
+}
+
+// RateStore keeps the token buckets state.
+// The in-process implementation is returned by NewMemoryStore; a shared store (e.g. Redis backed)
+// can be plugged in to apply limits across several instances.
+type RateStore interface {
+	// Take takes a token from the bucket identified by key, refilled according to limit.
+	Take(key string, limit Limit) RateResult
+}
+
+// KeyFunc returns the key identifying who a request is rate limited for.
+// Requests with an empty key aren't limited.
+type KeyFunc func(*http.Request) string
+
+// ClientIP is a KeyFunc returning the request remote IP.
+// Proxy headers such as X-Forwarded-For aren't trusted.
+func ClientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+
+	return r.RemoteAddr
+}
+
+// HeaderKey returns a KeyFunc using the value of a request header, e.g. an API key.
+func HeaderKey(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// ParamKey returns a KeyFunc using a route param, e.g. "tenant".
+func ParamKey(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return Param(r, name)
+	}
+}
+
+// RateLimitOptions configures the RateLimit Middleware.
+type RateLimitOptions struct {
+	// Limit applies to every route without its own limit in Routes
+	Limit Limit
+
+	// Routes sets limits by route pattern, e.g. "/v1/search", with their own buckets
+	Routes map[string]Limit
+
+	// Key identifies who requests are limited for. ClientIP by default.
+	Key KeyFunc
+
+	// Store keeps the buckets. An in-process store by default.
+	Store RateStore
+}
+
+// RateLimit returns a token bucket rate limiting Middleware.
+//
+// Every response gets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers.
+// Requests over the limit are answered with 429 Too Many Requests and a Retry-After header.
+func RateLimit(opts RateLimitOptions) Middleware {
+	if opts.Key == nil {
+		opts.Key = ClientIP
+	}
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			pattern := Pattern(req)
+			limit, ok := opts.Routes[pattern]
+			if !ok {
+				limit, pattern = opts.Limit, ""
+			}
+
+			key := opts.Key(req)
+			if limit.Requests <= 0 || limit.Period <= 0 || key == "" {
+				next.ServeHTTP(w, req)
+				return
+			}
+
+			res := opts.Store.Take(pattern+"\x00"+key, limit)
+
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+
+			if !res.Allowed {
+				w.Header().Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// seconds rounds d up to whole seconds.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// NewMemoryStore creates an in-process RateStore.
+// Buckets that have been full for a while are removed to keep memory bounded.
+func NewMemoryStore() RateStore {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// memoryStore implements RateStore in memory.
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	swept   time.Time
+}
+
+// bucket is a single token bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// Take implements RateStore
+func (s *memoryStore) Take(key string, limit Limit) RateResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	capacity := float64(limit.burst())
+	perToken := limit.Period / time.Duration(limit.Requests)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+
+	// Refill
+	b.tokens = math.Min(capacity, b.tokens+float64(now.Sub(b.last))/float64(perToken))
+	b.last = now
+
+	res := RateResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(perToken))
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((capacity - b.tokens) * float64(perToken))
+	b.full = now.Add(res.Reset)
+
+	s.sweep(now)
+
+	return res
+}
+
+// sweep removes the buckets full again, once a minute at most.
+func (s *memoryStore) sweep(now time.Time) {
+	if now.Sub(s.swept) < time.Minute {
+		return
+	}
+	s.swept = now
+
+	for k, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, k)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	r := New("/")
+	r.Add("/items", http.HandlerFunc(handler))
+	r.Add("/search", http.HandlerFunc(handler))
+	r.Wrap(RateLimit(RateLimitOptions{
+		Limit:  Limit{Requests: 2, Period: time.Minute},
+		Routes: map[string]Limit{"/search": {Requests: 1, Period: 10 * time.Second}},
+		Store:  store,
+	}))
+
+	d := Build(r)
+
+	serve := func(path, ip string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = ip + ":1234"
+		d.ServeHTTP(w, req)
+		return w
+	}
+
+	for i, remaining := range []string{"1", "0"} {
+		w := serve("/items", "10.0.0.1")
+		if w.Code != http.StatusOK {
+			t.Errorf("Request %d should be allowed. Got %d", i, w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != remaining {
+			t.Errorf("Request %d RateLimit headers aren't as expected: %v", i, w.Header())
+		}
+	}
+
+	w := serve("/items", "10.0.0.1")
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Request over the limit should get 429. Got %d", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "30" {
+		t.Errorf("Retry-After should be 30. Got %s", w.Header().Get("Retry-After"))
+	}
+	if w.Header().Get("RateLimit-Reset") != "60" {
+		t.Errorf("RateLimit-Reset should be 60. Got %s", w.Header().Get("RateLimit-Reset"))
+	}
+
+	// Other clients and routes have their own buckets
+	if w := serve("/items", "10.0.0.2"); w.Code != http.StatusOK {
+		t.Errorf("Other client should be allowed. Got %d", w.Code)
+	}
+	if w := serve("/search", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("Route with its own limit should be allowed. Got %d", w.Code)
+	}
+	if w := serve("/search", "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Route with its own limit should get 429. Got %d", w.Code)
+	}
+
+	// Refill
+	now = now.Add(30 * time.Second)
+	if w := serve("/items", "10.0.0.1"); w.Code != http.StatusOK {
+		t.Errorf("Request should be allowed after refill. Got %d", w.Code)
+	}
+}
+
+func TestRateLimitParamKey(t *testing.T) {
+	r := New("/")
+	r.Add("/:tenant/items", http.HandlerFunc(handler))
+	r.Wrap(RateLimit(RateLimitOptions{
+		Limit: Limit{Requests: 1, Period: time.Hour},
+		Key:   ParamKey("tenant"),
+	}))
+
+	d := Build(r)
+
+	for path, code := range map[string]int{"/acme/items": 200, "/other/items": 200} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != code {
+			t.Errorf("%s should respond %d. Got %d", path, code, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/acme/items", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("Second request for the same tenant should get 429. Got %d", w.Code)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	store.Take("a", Limit{Requests: 1, Period: time.Second})
+	now = now.Add(2 * time.Minute)
+	store.Take("b", Limit{Requests: 1, Period: time.Second})
+
+	if _, ok := store.buckets["a"]; ok {
+		t.Error("Full bucket should have been removed")
+	}
+	if _, ok := store.buckets["b"]; !ok {
+		t.Error("Bucket in use shouldn't have been removed")
+	}
+}
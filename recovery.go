@@ -0,0 +1,82 @@
+package router
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Panic holds the information about a panic recovered by the Dispatcher.
+type Panic struct {
+	// Value passed to panic()
+	Value interface{}
+
+	// Pattern of the matched route, empty if the panic happened before matching
+	Pattern string
+
+	// Route params
+	Params map[string]string
+
+	// Request ID set by the WithRequestID Middleware, if any
+	RequestID string
+
+	// Stack trace, only captured if enabled in Dispatcher.Recover
+	Stack []byte
+}
+
+// PanicHandler is called by the Dispatcher when a handler panics.
+// The response status has been set to 500 already unless the handler had written headers.
+type PanicHandler func(w http.ResponseWriter, r *http.Request, recovered *Panic)
+
+// logPanic is the default PanicHandler. It logs the panic with the standard logger.
+func logPanic(w http.ResponseWriter, r *http.Request, p *Panic) {
+	msg := fmt.Sprintf("router: panic serving %s %s (%s): %v", r.Method, r.URL.Path, p.Pattern, p.Value)
+	if p.RequestID != "" {
+		msg += " [request_id " + p.RequestID + "]"
+	}
+	if p.Stack != nil {
+		msg += "\n" + string(p.Stack)
+	}
+
+	log.Print(msg)
+}
+
+// recovery recovers from handler panics, writing a 500 response if headers weren't sent and calling the PanicHandler.
+// http.ErrAbortHandler panics are left to net/http, as they are meant to abort the response.
+func (d *dispatcher) recovery(w *responseWriter, req *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+
+	// Panics from route handlers running with a timeout come with their own stack
+	var stack []byte
+	if hp, ok := v.(*handlerPanic); ok {
+		v, stack = hp.value, hp.stack
+	}
+
+	p := &Panic{
+		Value:     v,
+		Pattern:   Pattern(req),
+		Params:    Params(req),
+		RequestID: RequestID(req),
+	}
+	if d.stack {
+		p.Stack = stack
+		if p.Stack == nil {
+			p.Stack = debug.Stack()
+		}
+	}
+
+	if w.status == 0 {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+
+	if d.panicHandler != nil {
+		d.panicHandler(w, req, p)
+	}
+}
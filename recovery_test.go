@@ -0,0 +1,99 @@
+package router
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	r := New("/")
+	r.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	var recovered *Panic
+	d := Build(r)
+	d.Recover(func(w http.ResponseWriter, r *http.Request, p *Panic) {
+		recovered = p
+		w.Write([]byte("recovered"))
+	}, true)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Response status should be 500. Got %d", w.Code)
+	}
+	if w.Body.String() != "recovered" {
+		t.Errorf("Response body should be 'recovered'. Got %s", w.Body.String())
+	}
+	if recovered == nil {
+		t.Fatal("PanicHandler should have been called")
+	}
+	if recovered.Value != "boom" {
+		t.Errorf("Panic value should be 'boom'. Got %v", recovered.Value)
+	}
+	if recovered.Pattern != "/users/:id" {
+		t.Errorf("Panic pattern should be '/users/:id'. Got %s", recovered.Pattern)
+	}
+	if recovered.Params["id"] != "42" {
+		t.Errorf("Panic params should have id '42'. Got %v", recovered.Params)
+	}
+	if len(recovered.Stack) == 0 {
+		t.Error("Panic stack should have been captured")
+	}
+}
+
+func TestRecoverAfterHeaders(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	var recovered *Panic
+	d := Build(r)
+	d.Recover(func(w http.ResponseWriter, r *http.Request, p *Panic) {
+		recovered = p
+	}, false)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Response status shouldn't change after headers were sent. Got %d", w.Code)
+	}
+	if recovered == nil {
+		t.Fatal("PanicHandler should have been called")
+	}
+	if recovered.Stack != nil {
+		t.Error("Panic stack shouldn't have been captured")
+	}
+}
+
+func TestRecoverDefaultLog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	Build(r).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Response status should be 500. Got %d", w.Code)
+	}
+	if !strings.Contains(buf.String(), "panic serving GET / (/): boom") {
+		t.Errorf("Panic should have been logged. Got %s", buf.String())
+	}
+}
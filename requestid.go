@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+type requestIDKey struct{}
+
+// RequestID returns the request ID set by the WithRequestID Middleware, if any.
+func RequestID(req *http.Request) string {
+	if id, ok := req.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+// WithRequestID returns a Middleware that takes the request ID from the header
+// (X-Request-ID if header is empty) or generates a new one when missing or invalid,
+// stores it in the request context for RequestID and echoes it on the response header.
+//
+// The request context is updated in place, so the request ID is also available to
+// the Dispatcher panic recovery and to middleware wrapping this one, such as AccessLog.
+func WithRequestID(header string) Middleware {
+	if header == "" {
+		header = "X-Request-ID"
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(header)
+			if !validRequestID(id) {
+				id = newRequestID()
+			}
+
+			*req = *req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id))
+			w.Header().Set(header, id)
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// validRequestID checks that a client provided ID is safe to log and echo:
+// not empty, not too long and made of printable ASCII characters only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 200 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID generates a random 128 bits request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,99 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var id string
+
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = RequestID(r)
+	}))
+
+	d := Build(r)
+	d.Wrap(WithRequestID(""))
+
+	// Propagated
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "abc-123")
+	d.ServeHTTP(w, req)
+	if id != "abc-123" {
+		t.Errorf("RequestID should be 'abc-123'. Got %s", id)
+	}
+	if w.Header().Get("X-Request-ID") != "abc-123" {
+		t.Errorf("X-Request-ID response header should be 'abc-123'. Got %s", w.Header().Get("X-Request-ID"))
+	}
+
+	// Generated
+	for _, header := range []string{"", "bad id\n"} {
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest("GET", "/", nil)
+		if header != "" {
+			req.Header.Set("X-Request-ID", header)
+		}
+		d.ServeHTTP(w, req)
+		if len(id) != 32 {
+			t.Errorf("RequestID should have been generated. Got '%s'", id)
+		}
+		if w.Header().Get("X-Request-ID") != id {
+			t.Errorf("X-Request-ID response header should be '%s'. Got %s", id, w.Header().Get("X-Request-ID"))
+		}
+	}
+}
+
+func TestRequestIDCustomHeader(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(handler))
+	r.Wrap(WithRequestID("X-Correlation-ID"))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Correlation-ID", "xyz")
+	Build(r).ServeHTTP(w, req)
+
+	if w.Header().Get("X-Correlation-ID") != "xyz" {
+		t.Errorf("X-Correlation-ID response header should be 'xyz'. Got %s", w.Header().Get("X-Correlation-ID"))
+	}
+}
+
+func TestRequestIDDispatcherLayers(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	var recovered *Panic
+	d := Build(r)
+	d.Wrap(WithRequestID(""))
+	d.Wrap(AccessLog(slog.New(slog.NewJSONHandler(buf, nil))))
+	d.Recover(func(w http.ResponseWriter, r *http.Request, p *Panic) {
+		recovered = p
+	}, false)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	id := w.Header().Get("X-Request-ID")
+	if recovered == nil || recovered.RequestID != id {
+		t.Errorf("Panic should have the request ID '%s'. Got %+v", id, recovered)
+	}
+
+	var record map[string]interface{}
+	json.Unmarshal(buf.Bytes(), &record)
+	if record["request_id"] != id {
+		t.Errorf("Access log should have the request ID '%s'. Got %v", id, record["request_id"])
+	}
+	if record["status"] != float64(500) {
+		t.Errorf("Access log should have the 500 status of the panicking request. Got %v", record["status"])
+	}
+}
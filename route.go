@@ -0,0 +1,230 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type routeKey struct{}
+
+// RouteInfo describes the route matched for a request.
+type RouteInfo struct {
+	// Pattern is the full route path, including the router prefix, e.g. "/v1/users/:id"
+	Pattern string
+
+	// Name is the route name set with Route.Name, if any
+	Name string
+
+	// Prefix is the prefix of the Router holding the route
+	Prefix string
+
+	// Methods allowed by the route. Empty means any.
+	Methods []string
+
+	// Requirements set with Route.Require
+	Requires []string
+
+	// MaxBodySize is the maximum request body size, in bytes, set on the route or its router. Zero means no limit.
+	MaxBodySize int64
+
+	// Accepts lists the request body media types accepted by the route or its router. Empty means any.
+	Accepts []string
+}
+
+// RouteFromContext returns the information of the route matched for the request the context belongs to.
+// It's nil if no route has been matched yet.
+func RouteFromContext(ctx context.Context) *RouteInfo {
+	rt, ok := ctx.Value(routeKey{}).(*Route)
+	if !ok {
+		return nil
+	}
+
+	return rt.info()
+}
+
+// Pattern returns the pattern of the route matched for the request, e.g. "/v1/users/:id".
+// It's empty if no route has been matched.
+func Pattern(req *http.Request) string {
+	if rt := matchedRoute(req); rt != nil {
+		return rt.pattern
+	}
+
+	return ""
+}
+
+// matchedRoute returns the Route matched for the request, if any.
+func matchedRoute(req *http.Request) *Route {
+	if rt, ok := req.Context().Value(routeKey{}).(*Route); ok {
+		return rt
+	}
+
+	return nil
+}
+
+// setRoute stores the matched Route in the request context.
+func setRoute(req *http.Request, rt *Route) {
+	*req = *req.WithContext(context.WithValue(req.Context(), routeKey{}, rt))
+}
+
+// Route is a single route definition returned by Router.Add.
+// It can be further configured by chaining its methods:
+//
+//	r.Add("/users", h).Methods("POST").When(router.ContentType("application/json"))
+type Route struct {
+	// Handler to execute on match
+	handler http.Handler
+
+	// Allowed HTTP methods. Empty means any.
+	methods []string
+
+	// Request matchers evaluated after the path matched
+	matchers []Matcher
+
+	// Tree node holding the route
+	node *node
+
+	// Full route path, including the router prefix
+	pattern string
+
+	// Route name
+	name string
+
+	// Router holding the route
+	router *router
+
+	// Route handler timeout, 0 to use the router one
+	timeout time.Duration
+
+	// Route level middleware
+	middleware []Middleware
+
+	// Authorization requirements
+	requires []string
+
+	// Request body limits, zero values to use the router ones
+	maxBodySize int64
+	accepts     []string
+
+	// Whether the route answers methods not allowed instead of being added to a router
+	synthetic bool
+}
+
+// info returns the RouteInfo describing the route.
+func (rt *Route) info() *RouteInfo {
+	size, types := rt.bodyLimits()
+
+	return &RouteInfo{
+		Pattern:     rt.pattern,
+		Name:        rt.name,
+		Prefix:      rt.router.prefix,
+		Methods:     append([]string{}, rt.methods...),
+		Requires:    append([]string{}, rt.requires...),
+		MaxBodySize: size,
+		Accepts:     append([]string{}, types...),
+	}
+}
+
+// Name sets a name for the route, available to handlers and middleware through RouteFromContext.
+func (rt *Route) Name(name string) *Route {
+	rt.name = name
+
+	return rt
+}
+
+// Methods restricts the route to the given HTTP methods.
+func (rt *Route) Methods(methods ...string) *Route {
+	for _, m := range methods {
+		rt.methods = append(rt.methods, strings.ToUpper(m))
+	}
+
+	return rt
+}
+
+// Wrap takes a Middleware to wrap the route handler in order (from inside out) at route level.
+// Route middleware runs inside the router and dispatcher ones.
+func (rt *Route) Wrap(m Middleware) *Route {
+	rt.middleware = append(rt.middleware, m)
+
+	return rt
+}
+
+// Require adds authorization requirements to the route, e.g. "users:write".
+// Before calling the route handler, and after all middleware, the Dispatcher Authorizer must allow the request.
+// Requests without an authenticated Principal are answered with 401 and denied ones with 403.
+func (rt *Route) Require(requirements ...string) *Route {
+	rt.requires = append(rt.requires, requirements...)
+
+	return rt
+}
+
+// MaxBodySize limits the request body size, overriding the Router limit.
+// Requests declaring a larger Content-Length are answered with 413 Request Entity Too Large,
+// and reading a larger body fails with *http.MaxBytesError.
+func (rt *Route) MaxBodySize(n int64) *Route {
+	rt.maxBodySize = n
+
+	return rt
+}
+
+// Accepts restricts the request body media types, e.g. "application/json" or "image/*", overriding the Router ones.
+// Requests with a body of another media type are answered with 415 Unsupported Media Type.
+func (rt *Route) Accepts(types ...string) *Route {
+	rt.accepts = append(rt.accepts, types...)
+
+	return rt
+}
+
+// Timeout sets the time the route handler has to respond, overriding the Router timeout.
+// The route handler request context is cancelled at the deadline and the Dispatcher timeout handler answers
+// if the response wasn't started yet.
+func (rt *Route) Timeout(d time.Duration) *Route {
+	rt.timeout = d
+
+	return rt
+}
+
+// When adds Matchers that the request must satisfy for the route to match.
+// If any of them fails, the router keeps looking for another matching route.
+func (rt *Route) When(matchers ...Matcher) *Route {
+	rt.matchers = append(rt.matchers, matchers...)
+
+	return rt
+}
+
+// matches checks the request method, unless anyMethod is set, and matchers against the route.
+func (rt *Route) matches(r *http.Request, anyMethod bool) bool {
+	if rt.handler == nil {
+		return false
+	}
+
+	if !anyMethod && len(rt.methods) > 0 && !contains(rt.methods, r.Method) {
+		return false
+	}
+
+	return matchAll(rt.matchers, r)
+}
+
+// methodNotAllowed returns a Route for a path whose routes don't allow the request method.
+// It answers OPTIONS requests with 204 and any other method with 405, listing the allowed methods in the Allow header.
+func methodNotAllowed(n *node, r *router, methods []string) *Route {
+	allow := strings.Join(append(append([]string{}, methods...), http.MethodOptions), ", ")
+
+	return &Route{
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		}),
+		methods:   methods,
+		node:      n,
+		pattern:   n.buildPath(),
+		router:    r,
+		synthetic: true,
+	}
+}
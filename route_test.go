@@ -0,0 +1,120 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRouteMethods(t *testing.T) {
+	r := New("/")
+	r.Add("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("create"))
+	})).Methods("post")
+	r.Add("/users", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("list"))
+	})).Methods("GET", "HEAD")
+
+	d := Build(r)
+
+	for method, body := range map[string]string{"GET": "list", "POST": "create"} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest(method, "/users", nil))
+		if w.Body.String() != body {
+			t.Errorf("%s /users should respond '%s'. Got %s", method, body, w.Body.String())
+		}
+	}
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("DELETE", "/users", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE /users should respond 405. Got %d", w.Code)
+	}
+	if w.Header().Get("Allow") != "POST, GET, HEAD, OPTIONS" {
+		t.Errorf("Allow header should be 'POST, GET, HEAD, OPTIONS'. Got %s", w.Header().Get("Allow"))
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("OPTIONS", "/users", nil))
+	if w.Code != http.StatusNoContent {
+		t.Errorf("OPTIONS /users should respond 204. Got %d", w.Code)
+	}
+
+	req := httptest.NewRequest("DELETE", "/other", nil)
+	if h := r.Match(req); h != nil {
+		t.Error("DELETE /other shouldn't have matched our routes")
+	}
+}
+
+func TestRouteAddExistingPath(t *testing.T) {
+	r := New("/")
+	r.Add("/a/b", http.HandlerFunc(handler))
+	r.Add("/a", http.HandlerFunc(handler))
+
+	req := httptest.NewRequest("GET", "/a", nil)
+	if h := r.Match(req); h == nil {
+		t.Error("/a should match after adding /a/b first")
+	}
+}
+
+func TestRouteFromContext(t *testing.T) {
+	var (
+		info    *RouteInfo
+		pattern string
+	)
+
+	r := New("/v1")
+	r.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info = RouteFromContext(r.Context())
+		pattern = Pattern(r)
+	})).Name("user").Methods("GET", "HEAD")
+
+	req := httptest.NewRequest("GET", "/v1/users/42", nil)
+	if info := RouteFromContext(req.Context()); info != nil {
+		t.Errorf("RouteFromContext should be nil before matching. Got %v", info)
+	}
+	if Pattern(req) != "" {
+		t.Errorf("Pattern should be empty before matching. Got %s", Pattern(req))
+	}
+
+	Build(r).ServeHTTP(httptest.NewRecorder(), req)
+
+	if pattern != "/v1/users/:id" {
+		t.Errorf("Pattern should be '/v1/users/:id'. Got %s", pattern)
+	}
+	if info == nil {
+		t.Fatal("RouteFromContext shouldn't be nil after matching")
+	}
+	if info.Pattern != "/v1/users/:id" || info.Name != "user" || info.Prefix != "/v1" {
+		t.Errorf("RouteInfo isn't as expected: %+v", info)
+	}
+	if len(info.Methods) != 2 || info.Methods[0] != "GET" || info.Methods[1] != "HEAD" {
+		t.Errorf("RouteInfo methods should be [GET HEAD]. Got %v", info.Methods)
+	}
+}
+
+func TestRouteWrap(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Write([]byte("Handler"))
+	})).Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte("2"))
+			next.ServeHTTP(res, req)
+		})
+	})
+
+	r.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			res.Write([]byte("1"))
+			next.ServeHTTP(res, req)
+		})
+	})
+
+	w := httptest.NewRecorder()
+	Build(r).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Body.String() != "12Handler" {
+		t.Errorf("Route middleware should run inside the router one. Got %s", w.Body.String())
+	}
+}
@@ -1,23 +1,67 @@
 package router
 
 import (
+	"io/fs"
 	"net/http"
 	"path"
+	"time"
 )
 
 // Router implements the needed methods for the Dispatcher
 // to be able to match and execute requests.
+// Routers are created by New: the interface can't be implemented outside this package,
+// as the Dispatcher ranks routers by their routes and settings.
 type Router interface {
-	// Add takes a route path and a handler to store for further matching
-	Add(path string, handler http.Handler)
+	// Add takes a route path and a handler to store for further matching.
+	// It returns the Route created so it can be further configured.
+	Add(path string, handler http.Handler) *Route
 
 	// Wrap takes a Middleware to wrap all handlers in order (from inside out) at router level.
 	Wrap(Middleware)
 
+	// IgnoreCase enables case-insensitive matching of static route parts.
+	// Param values are kept as sent by the client.
+	IgnoreCase()
+
+	// Normalize sets a function applied to static route parts and request path parts before comparing them.
+	// It's meant to plug a Unicode normalization form (e.g. NFC) so equivalent paths match the same route.
+	Normalize(func(string) string)
+
+	// Host binds the router to a host pattern, so it only matches requests for that host.
+	// Patterns can contain static labels ("api.example.com"), parameters (":tenant.example.com")
+	// and a leading wildcard ("*.example.com"). Host parameters are available through Params.
+	Host(pattern string)
+
+	// When adds Matchers that requests must satisfy, after the path matched, for the router to match.
+	When(...Matcher)
+
+	// Priority sets the router priority in the Dispatcher, 0 by default.
+	// When several routers match a request, the one with the highest priority wins
+	// and, on equal priority, the one with the most specific route.
+	Priority(int)
+
+	// Timeout sets the time the handlers of the router have to respond. See Route.Timeout.
+	Timeout(time.Duration)
+
+	// MaxBodySize limits the request body size of the router routes. See Route.MaxBodySize.
+	MaxBodySize(int64)
+
+	// Accepts restricts the request body media types of the router routes. See Route.Accepts.
+	Accepts(...string)
+
+	// Static serves the files of a file system under prefix. See Static.
+	Static(prefix string, fsys fs.FS) *Static
+
 	// Match checks if a request matches this router.
 	// If so, adds the route parameters to the request context and returns the corresponding handler.
+	// If the path matches routes restricted to other methods, the handler answers
+	// OPTIONS requests with 204 and other requests with 405, setting the Allow header.
+	// The Dispatcher ranks routers answering 405 by priority and specificity too.
 	// If route doesn't matches, the response is nil
 	Match(*http.Request) http.Handler
+
+	// impl returns the router implementation.
+	impl() *router
 }
 
 // New creates a new Router with the provided prefix
@@ -40,20 +84,161 @@ type router struct {
 
 	// Middlewares collection
 	middleware []Middleware
+
+	// Static parts comparison settings
+	compare compare
+
+	// Host pattern, if bound to one
+	host *host
+
+	// Router level request matchers
+	matchers []Matcher
+
+	// Dispatcher priority
+	priority int
+
+	// Route handlers timeout, 0 for none
+	timeout time.Duration
+
+	// Request body limits
+	maxBodySize int64
+	accepts     []string
 }
 
-func (r *router) Add(route string, h http.Handler) {
-	r.tree.add(path.Join(r.prefix, route), h)
+func (r *router) Add(route string, h http.Handler) *Route {
+	rt := r.tree.add(path.Join(r.prefix, route), h)
+	rt.router = r
+
+	return rt
+}
+
+func (r *router) impl() *router {
+	return r
 }
 
 func (r *router) Wrap(m Middleware) {
 	r.middleware = append(r.middleware, m)
 }
 
+func (r *router) IgnoreCase() {
+	r.compare.fold = true
+}
+
+func (r *router) Normalize(f func(string) string) {
+	r.compare.normalize = f
+}
+
+func (r *router) Host(pattern string) {
+	r.host = parseHost(pattern)
+}
+
+func (r *router) When(matchers ...Matcher) {
+	r.matchers = append(r.matchers, matchers...)
+}
+
+func (r *router) Priority(p int) {
+	r.priority = p
+}
+
+func (r *router) Timeout(d time.Duration) {
+	r.timeout = d
+}
+
+func (r *router) MaxBodySize(n int64) {
+	r.maxBodySize = n
+}
+
+func (r *router) Accepts(types ...string) {
+	r.accepts = append(r.accepts, types...)
+}
+
 func (r *router) Match(req *http.Request) http.Handler {
-	h := r.tree.match(req)
-	for _, m := range r.middleware {
-		h = m(h)
+	rt, params := r.lookup(req)
+	if rt == nil || !rt.static() {
+		// More specific paths restricted to other methods outrank params and wildcards
+		if m, p := r.lookupMethods(req); m != nil && (rt == nil || moreSpecific(m, rt)) {
+			rt, params = m, p
+		}
+	}
+	if rt == nil {
+		return nil
+	}
+
+	return r.handle(req, rt, params, nil)
+}
+
+// lookup finds the route matching the request and its params, without adding them to the request context.
+func (r *router) lookup(req *http.Request) (*Route, map[string]string) {
+	params := make(map[string]string)
+
+	// Check host first
+	if r.host != nil && !r.host.match(req, params) {
+		return nil, nil
+	}
+
+	rt := r.tree.match(req, params, r.compare)
+	if rt == nil || !matchAll(r.matchers, req) {
+		return nil, nil
+	}
+
+	return rt, params
+}
+
+// lookupMethods finds the path matching the request when no route allows the request method.
+// It returns a Route answering with the methods allowed, or nil if the path doesn't match any route.
+func (r *router) lookupMethods(req *http.Request) (*Route, map[string]string) {
+	params := make(map[string]string)
+
+	if r.host != nil && !r.host.match(req, params) {
+		return nil, nil
+	}
+
+	c := r.compare
+	c.anyMethod = true
+
+	rt := r.tree.match(req, params, c)
+	if rt == nil || !matchAll(r.matchers, req) {
+		return nil, nil
+	}
+
+	methods := rt.node.methods(req)
+	if len(methods) == 0 {
+		return nil, nil
+	}
+
+	return methodNotAllowed(rt.node, r, methods), params
+}
+
+// handle adds the params to the request context and returns the route handler wrapped by the route and router middleware.
+// Body limits are enforced before the route and router middleware. If guard isn't nil, it wraps the route handler and each middleware layer.
+func (r *router) handle(req *http.Request, rt *Route, params map[string]string, guard Middleware) http.Handler {
+	// Set params if needed
+	setParams(req, params)
+	setRoute(req, rt)
+
+	h := rt.handler
+	if t := rt.routeTimeout(); t > 0 {
+		h = rt.withTimeout(h, t)
+	}
+	if len(rt.requires) > 0 {
+		h = rt.authorize(h)
+	}
+	if guard != nil {
+		h = guard(h)
+	}
+	for _, mw := range [][]Middleware{rt.middleware, r.middleware} {
+		for _, m := range mw {
+			h = m(h)
+			if guard != nil {
+				h = guard(h)
+			}
+		}
+	}
+
+	if !rt.synthetic {
+		if size, types := rt.bodyLimits(); size > 0 || len(types) > 0 {
+			h = rt.limit(h)
+		}
 	}
 
 	return h
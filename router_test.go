@@ -2,6 +2,7 @@ package router
 
 import (
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -161,3 +162,47 @@ func TestCatchAllRoute(t *testing.T) {
 		t.Errorf("%s should have matched our routes", "http://example.com/wrong/but/something/valid/or/else")
 	}
 }
+
+func TestIgnoreCaseMatch(t *testing.T) {
+	r := New("/v1")
+	r.Add("/users/:id", http.HandlerFunc(handler))
+
+	req, _ := http.NewRequest("GET", "http://example.com/V1/Users/AbC", nil)
+	if h := r.Match(req); h != nil {
+		t.Errorf("%s shouldn't have matched before enabling IgnoreCase", req.URL)
+	}
+
+	r.IgnoreCase()
+
+	for _, match := range []string{
+		"http://example.com/v1/users/AbC",
+		"http://example.com/V1/Users/AbC",
+		"http://example.com/v1/USERS/AbC",
+	} {
+		req, _ := http.NewRequest("GET", match, nil)
+		h := r.Match(req)
+		if h == nil {
+			t.Errorf("%s should have matched our routes", match)
+		} else if Param(req, "id") != "AbC" {
+			t.Errorf("Param :id should keep its casing 'AbC'. Got %s", Param(req, "id"))
+		}
+	}
+}
+
+func TestNormalizeMatch(t *testing.T) {
+	r := New("/")
+	r.Add("/caf\u00e9/:name", http.HandlerFunc(handler))
+
+	// Compose the only decomposed form used by this test.
+	r.Normalize(func(s string) string {
+		return strings.Replace(s, "e\u0301", "\u00e9", -1)
+	})
+
+	req, _ := http.NewRequest("GET", "http://example.com/cafe\u0301/Joe", nil)
+	h := r.Match(req)
+	if h == nil {
+		t.Errorf("%s should have matched our routes", req.URL)
+	} else if Param(req, "name") != "Joe" {
+		t.Errorf("Param :name should be set to 'Joe'. Got %s", Param(req, "name"))
+	}
+}
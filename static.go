@@ -0,0 +1,248 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Static serves the files of a file system under a router prefix, created by Router.Static.
+// It can be further configured by chaining its methods:
+//
+//	r.Static("/assets", os.DirFS("public")).MaxAge(24 * time.Hour).NoListing()
+type Static struct {
+	// Files served
+	fsys fs.FS
+
+	// Full path prefix, including the router prefix
+	prefix string
+
+	// Cache-Control max-age, 0 to always revalidate
+	maxAge time.Duration
+
+	// Whether directories without index.html are listed
+	listing bool
+
+	// Whether unknown paths fall back to the root index.html, and the path prefixes that never do
+	spa     bool
+	exclude []string
+
+	// Routes serving the prefix and everything under it
+	routes []*Route
+}
+
+// Static serves the files of fsys under prefix with http.FileServer semantics:
+// Range, If-Modified-Since and If-None-Match requests are supported and directories are served
+// from their index.html or listed. Only GET and HEAD requests are allowed.
+//
+// Responses get Last-Modified, ETag and Cache-Control headers. If the client accepts it,
+// a precompressed "<file>.br" or "<file>.gz" sidecar file is served instead of the file.
+// Other routes are more specific than the catch-all static routes, so they keep precedence,
+// and requests for paths restricted to other methods are answered with 405.
+func (r *router) Static(prefix string, fsys fs.FS) *Static {
+	s := &Static{
+		fsys:    fsys,
+		prefix:  path.Join("/", r.prefix, prefix),
+		listing: true,
+	}
+
+	s.routes = append(s.routes,
+		r.Add(prefix, s).Methods(http.MethodGet, http.MethodHead),
+		r.Add(path.Join(prefix, "*"), s).Methods(http.MethodGet, http.MethodHead),
+	)
+
+	return s
+}
+
+// MaxAge sets how long clients can cache files without revalidating them.
+// By default "Cache-Control: no-cache" is sent so clients always revalidate.
+func (s *Static) MaxAge(d time.Duration) *Static {
+	s.maxAge = d
+
+	return s
+}
+
+// NoListing disables directory listings. Directories without index.html are answered with 404.
+func (s *Static) NoListing() *Static {
+	s.listing = false
+
+	return s
+}
+
+// SPA enables single-page app mode: GET and HEAD requests accepting HTML for unknown paths
+// without file extension are answered with the root index.html, so client side routes work on reload.
+// Missing assets, and paths under any of the exclude prefixes (e.g. "/api"), are still answered with 404.
+func (s *Static) SPA(exclude ...string) *Static {
+	s.spa = true
+	for _, p := range exclude {
+		s.exclude = append(s.exclude, path.Join("/", p))
+	}
+
+	return s
+}
+
+// Wrap takes a Middleware to wrap the static routes in order (from inside out) at route level.
+func (s *Static) Wrap(m Middleware) *Static {
+	for _, rt := range s.routes {
+		rt.Wrap(m)
+	}
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Static) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Path
+	if len(name) >= len(s.prefix) && strings.EqualFold(name[:len(s.prefix)], s.prefix) {
+		// Prefix may differ in case on routers ignoring it
+		name = name[len(s.prefix):]
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		http.NotFound(w, req)
+		return
+	}
+
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		if index, err := fs.Stat(s.fsys, path.Join(name, "index.html")); err == nil && !index.IsDir() {
+			s.serveFile(w, req, path.Join(name, "index.html"), index)
+			return
+		}
+		if s.listing {
+			s.serveDir(w, req, name)
+			return
+		}
+	} else if err == nil {
+		s.serveFile(w, req, name, info)
+		return
+	}
+
+	// Not found
+	if s.fallback(req, name) {
+		if index, err := fs.Stat(s.fsys, "index.html"); err == nil && !index.IsDir() {
+			s.serveFile(w, req, "index.html", index)
+			return
+		}
+	}
+
+	http.NotFound(w, req)
+}
+
+// fallback reports whether the request for a missing file should be answered with the SPA index.html.
+func (s *Static) fallback(req *http.Request, name string) bool {
+	if !s.spa || path.Ext(name) != "" {
+		return false
+	}
+
+	p := path.Clean("/" + req.URL.Path)
+	for _, prefix := range s.exclude {
+		if prefix == "/" || p == prefix || strings.HasPrefix(p, prefix+"/") {
+			return false
+		}
+	}
+
+	for _, av := range parseAccept(req.Header.Get("Accept")) {
+		if av.value == "text/html" && av.q > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// serveFile serves a file, or its precompressed sidecar if the client accepts its encoding.
+func (s *Static) serveFile(w http.ResponseWriter, req *http.Request, name string, info fs.FileInfo) {
+	h := w.Header()
+
+	// Cache headers
+	if s.maxAge > 0 && path.Base(name) != "index.html" {
+		h.Set("Cache-Control", "public, max-age="+strconv.FormatInt(int64(s.maxAge/time.Second), 10))
+	} else {
+		h.Set("Cache-Control", "no-cache")
+	}
+	h.Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+
+	ct := mime.TypeByExtension(path.Ext(name))
+	if ct != "" {
+		h.Set("Content-Type", ct)
+	}
+
+	// Precompressed sidecars
+	addVary(h, "Accept-Encoding")
+	sidecars := make([]string, 0, 2)
+	for _, enc := range []string{"br", "gzip"} {
+		if sc, err := fs.Stat(s.fsys, name+sidecarExt[enc]); err == nil && !sc.IsDir() {
+			sidecars = append(sidecars, enc)
+		}
+	}
+	if enc := acceptEncoding(req.Header.Get("Accept-Encoding"), sidecars...); enc != "" && req.Header.Get("Range") == "" {
+		if ct == "" {
+			h.Set("Content-Type", "application/octet-stream")
+		}
+		h.Set("Content-Encoding", enc)
+		name += sidecarExt[enc]
+	}
+
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		h.Del("Content-Encoding")
+		http.NotFound(w, req)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		b, err := io.ReadAll(f)
+		if err != nil {
+			h.Del("Content-Encoding")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(b)
+	}
+
+	http.ServeContent(w, req, name, info.ModTime(), content)
+}
+
+// sidecarExt maps encodings to the extension of their precompressed sidecar files.
+var sidecarExt = map[string]string{
+	"br":   ".br",
+	"gzip": ".gz",
+}
+
+// serveDir writes an HTML listing of the directory.
+func (s *Static) serveDir(w http.ResponseWriter, req *http.Request, name string) {
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	fmt.Fprintln(w, "<!doctype html>\n<meta name=\"viewport\" content=\"width=device-width\">\n<pre>")
+	for _, e := range entries {
+		n := e.Name()
+		if e.IsDir() {
+			n += "/"
+		}
+		link := url.URL{Path: path.Join(req.URL.Path, e.Name())}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", link.String(), html.EscapeString(n))
+	}
+	fmt.Fprintln(w, "</pre>")
+}
@@ -0,0 +1,200 @@
+package router
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func staticFS() fstest.MapFS {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("console.log('app')"))
+	zw.Close()
+
+	modified := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	return fstest.MapFS{
+		"index.html":      {Data: []byte("<html>app</html>"), ModTime: modified},
+		"app.js":          {Data: []byte("console.log('app')"), ModTime: modified},
+		"app.js.gz":       {Data: gz.Bytes(), ModTime: modified},
+		"docs/readme.txt": {Data: []byte("readme"), ModTime: modified},
+		"docs/<b>.txt":    {Data: []byte("bold"), ModTime: modified},
+	}
+}
+
+func TestStatic(t *testing.T) {
+	r := New("/")
+	r.Static("/assets", staticFS()).MaxAge(time.Hour)
+
+	d := Build(r)
+
+	for _, c := range []struct {
+		method, path string
+		code         int
+		body, cache  string
+	}{
+		{"GET", "/assets/app.js", http.StatusOK, "console.log('app')", "public, max-age=3600"},
+		{"HEAD", "/assets/app.js", http.StatusOK, "", "public, max-age=3600"},
+		{"GET", "/assets", http.StatusOK, "<html>app</html>", "no-cache"},
+		{"GET", "/assets/", http.StatusOK, "<html>app</html>", "no-cache"},
+		{"GET", "/assets/index.html", http.StatusOK, "<html>app</html>", "no-cache"},
+		{"GET", "/assets/docs/readme.txt", http.StatusOK, "readme", "public, max-age=3600"},
+		{"GET", "/assets/missing.js", http.StatusNotFound, "", ""},
+		{"POST", "/assets/app.js", http.StatusMethodNotAllowed, "", ""},
+	} {
+		w := httptest.NewRecorder()
+		d.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+
+		if w.Code != c.code {
+			t.Errorf("%s %s should respond %d. Got %d", c.method, c.path, c.code, w.Code)
+			continue
+		}
+		if c.code == http.StatusOK {
+			if w.Body.String() != c.body {
+				t.Errorf("%s %s body should be '%s'. Got '%s'", c.method, c.path, c.body, w.Body.String())
+			}
+			if w.Header().Get("Cache-Control") != c.cache {
+				t.Errorf("%s %s Cache-Control should be '%s'. Got '%s'", c.method, c.path, c.cache, w.Header().Get("Cache-Control"))
+			}
+			if w.Header().Get("Last-Modified") == "" || w.Header().Get("ETag") == "" {
+				t.Errorf("%s %s should have Last-Modified and ETag headers", c.method, c.path)
+			}
+		}
+	}
+
+	// Revalidation
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/assets/app.js", nil))
+
+	req := httptest.NewRequest("GET", "/assets/app.js", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Request with matching ETag should respond 304. Got %d", w.Code)
+	}
+}
+
+func TestStaticTraversal(t *testing.T) {
+	s := New("/").Static("/assets", fstest.MapFS{
+		"public.txt": {Data: []byte("public")},
+	})
+
+	// Served directly, without the router path cleanup
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.URL.Path = "/assets/../../public.txt"
+	s.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Body.String() != "public" {
+		t.Errorf("Path should be resolved inside the file system. Got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	r := New("/")
+	r.Static("/", staticFS())
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	d.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Gzip sidecar should be served. Got Content-Encoding '%s'", w.Header().Get("Content-Encoding"))
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/javascript") {
+		t.Errorf("Content-Type should be the original file one. Got %s", w.Header().Get("Content-Type"))
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("Response should vary on Accept-Encoding. Got %s", w.Header().Get("Vary"))
+	}
+
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	b.ReadFrom(zr)
+	if b.String() != "console.log('app')" {
+		t.Errorf("Decompressed body isn't as expected. Got %s", b.String())
+	}
+
+	// Not accepted
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/app.js", nil))
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != "console.log('app')" {
+		t.Errorf("Original file should be served. Got Content-Encoding '%s'", w.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestStaticListing(t *testing.T) {
+	r := New("/")
+	r.Static("/files", staticFS())
+	r.Static("/private", staticFS()).NoListing()
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/files/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `<a href="/files/docs/readme.txt">readme.txt</a>`) {
+		t.Errorf("Directory should be listed. Got %d %s", w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Body.String(), "&lt;b&gt;.txt") {
+		t.Errorf("Listed names should be escaped. Got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/private/docs", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Directory listing should be disabled. Got %d", w.Code)
+	}
+}
+
+func TestStaticSPA(t *testing.T) {
+	api := New("/api")
+	api.Add("/users", bodyHandler("users"))
+	api.Add("/orders", bodyHandler("orders")).Methods("POST")
+
+	app := New("/")
+	app.Add("/signup", bodyHandler("signup")).Methods("POST")
+	app.Static("/", staticFS()).SPA("/api")
+
+	d := Build(app, api)
+
+	for _, c := range []struct {
+		path, accept string
+		code         int
+		body         string
+	}{
+		{"/users/10", "text/html,application/xhtml+xml,*/*;q=0.8", http.StatusOK, "<html>app</html>"},
+		{"/app.js", "*/*", http.StatusOK, "console.log('app')"},
+		{"/missing.js", "text/html", http.StatusNotFound, ""},
+		{"/users/10", "application/json", http.StatusNotFound, ""},
+		{"/api/users", "application/json", http.StatusOK, "users"},
+		{"/api/orders", "text/html", http.StatusMethodNotAllowed, ""},
+		{"/signup", "text/html", http.StatusMethodNotAllowed, ""},
+		{"/api/unknown", "text/html", http.StatusNotFound, ""},
+		{"/apis", "text/html", http.StatusOK, "<html>app</html>"},
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", c.path, nil)
+		req.Header.Set("Accept", c.accept)
+		d.ServeHTTP(w, req)
+
+		if w.Code != c.code {
+			t.Errorf("%s accepting %s should respond %d. Got %d", c.path, c.accept, c.code, w.Code)
+		}
+		if c.body != "" && w.Body.String() != c.body {
+			t.Errorf("%s accepting %s body should be '%s'. Got '%s'", c.path, c.accept, c.body, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,345 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// routeTimeout returns the timeout of the route, falling back to the router one.
+func (rt *Route) routeTimeout() time.Duration {
+	if rt.timeout > 0 || rt.router == nil {
+		return rt.timeout
+	}
+
+	return rt.router.timeout
+}
+
+// handlerPanic carries a panic from the goroutine running a route handler with a timeout,
+// along with the stack where it happened.
+type handlerPanic struct {
+	value interface{}
+	stack []byte
+}
+
+// withTimeout returns the route handler running with a context deadline,
+// answering with the Dispatcher timeout handler when it expires.
+//
+// Unlike http.TimeoutHandler, the response isn't buffered: writes go straight to the client until the deadline,
+// so streaming works, and writes after the deadline, or after the request context is canceled,
+// fail with http.ErrHandlerTimeout instead of corrupting the response.
+// Only the route handler runs in its own goroutine, with a shallow copy of the request and its own header map,
+// copied to the response when headers are written. Middleware run in the request goroutine and see the timeout response.
+func (rt *Route) withTimeout(h http.Handler, t time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		d := dispatcherFrom(req)
+
+		ctx, cancel := context.WithTimeout(req.Context(), t)
+		defer cancel()
+		hreq := req.WithContext(ctx)
+
+		tw := &timeoutWriter{w: w, h: make(http.Header), ctx: ctx}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					var stack []byte
+					if p != http.ErrAbortHandler {
+						stack = debug.Stack()
+					}
+
+					tw.mu.Lock()
+					late := tw.timedOut
+					if !late {
+						if stack != nil {
+							panicked <- &handlerPanic{value: p, stack: stack}
+						} else {
+							panicked <- p
+						}
+					}
+					tw.mu.Unlock()
+
+					// Nobody is waiting for the handler anymore
+					if late && p != http.ErrAbortHandler {
+						d.latePanic(tw, hreq, p, stack)
+					}
+				}
+				close(done)
+
+				tw.mu.Lock()
+				tw.returned = true
+				release := tw.release
+				tw.mu.Unlock()
+				if release != nil {
+					release()
+				}
+			}()
+
+			h.ServeHTTP(tw.writer(), hreq)
+		}()
+
+		select {
+		case <-done:
+		case <-ctx.Done():
+		}
+
+		// Keep the request in flight, for Drain and the route counts, until the handler returns
+		tw.mu.Lock()
+		if !tw.returned {
+			tw.release = d.hold(Pattern(req))
+		}
+		tw.mu.Unlock()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		default:
+		}
+
+		// Handlers returning as soon as the deadline expires still get the timeout response.
+		// If the request context is done for another reason, e.g. the client went away, the handler
+		// can't write anymore either, as the response is over once this returns.
+		switch err := ctx.Err(); {
+		case err == context.DeadlineExceeded:
+			tw.timeout(d.timeoutResponse(), hreq)
+		case err != nil:
+			tw.timeout(nil, hreq)
+		}
+		tw.finish()
+	})
+}
+
+// dispatcherFrom returns the Dispatcher serving the request, nil for routers used on their own.
+func dispatcherFrom(req *http.Request) *dispatcher {
+	d, _ := req.Context().Value(dispatcherKey{}).(*dispatcher)
+
+	return d
+}
+
+// timeoutResponse returns the handler answering timed out requests.
+func (d *dispatcher) timeoutResponse() http.Handler {
+	if d == nil || d.timeoutHandler == nil {
+		return http.HandlerFunc(serviceUnavailable)
+	}
+
+	return d.timeoutHandler
+}
+
+// latePanic reports a panic from a handler that timed out, as its response is already sent.
+func (d *dispatcher) latePanic(w http.ResponseWriter, req *http.Request, v interface{}, stack []byte) {
+	handler := logPanic
+	if d != nil {
+		handler = d.panicHandler
+		if !d.stack {
+			stack = nil
+		}
+	}
+	if handler == nil {
+		return
+	}
+
+	handler(w, req, &Panic{
+		Value:     v,
+		Pattern:   Pattern(req),
+		Params:    Params(req),
+		RequestID: RequestID(req),
+		Stack:     stack,
+	})
+}
+
+// serviceUnavailable is the default timeout handler.
+func serviceUnavailable(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+}
+
+// timeoutWriter guards the response from handlers running after their deadline.
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	// Handler context, whose deadline is the route timeout
+	ctx context.Context
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+	hijacked    bool
+
+	// Whether the handler returned, and the function ending its in-flight count if it outlived the request
+	returned bool
+	release  func()
+}
+
+// writer returns the timeoutWriter implementing the optional interfaces the wrapped writer implements.
+func (tw *timeoutWriter) writer() http.ResponseWriter {
+	_, hijacker := tw.w.(http.Hijacker)
+	_, pusher := tw.w.(http.Pusher)
+
+	switch {
+	case hijacker && pusher:
+		return timeoutHijackPushWriter{tw}
+	case hijacker:
+		return timeoutHijackWriter{tw}
+	case pusher:
+		return timeoutPushWriter{tw}
+	}
+
+	return tw
+}
+
+// timeout marks the writer as timed out, so the handler can't write anymore,
+// and writes the timeout response with h, if not nil, when possible.
+func (tw *timeoutWriter) timeout(h http.Handler, req *http.Request) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.timedOut = true
+	if h != nil && !tw.wroteHeader && !tw.hijacked {
+		tw.wroteHeader = true
+		h.ServeHTTP(tw.w, req)
+	}
+}
+
+// expired reports whether the handler context is done, by the deadline or the request context,
+// even if the writer wasn't marked as timed out yet, so handlers reacting to it can't write first.
+// Must be called with the lock held.
+func (tw *timeoutWriter) expired() bool {
+	return tw.timedOut || tw.ctx.Err() != nil
+}
+
+// finish copies the handler headers if the handler didn't write any.
+func (tw *timeoutWriter) finish() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.wroteHeader && !tw.timedOut {
+		copyHeader(tw.w.Header(), tw.h)
+	}
+}
+
+// Header implements http.ResponseWriter
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+// WriteHeader implements http.ResponseWriter
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	tw.writeHeader(code)
+}
+
+// writeHeader copies the handler headers and writes the status. Must be called with the lock held.
+func (tw *timeoutWriter) writeHeader(code int) {
+	if tw.expired() || tw.wroteHeader {
+		return
+	}
+
+	tw.wroteHeader = true
+	copyHeader(tw.w.Header(), tw.h)
+	tw.w.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeader(http.StatusOK)
+
+	return tw.w.Write(b)
+}
+
+// Flush implements http.Flusher. It's a no-op if the wrapped writer isn't a Flusher.
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if !tw.expired() {
+		tw.writeHeader(http.StatusOK)
+		if f, ok := tw.w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
+// hijack implements http.Hijacker for the writers wrapping one. The timeout response can't be sent after hijacking.
+func (tw *timeoutWriter) hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired() {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+
+	conn, rw, err := tw.w.(http.Hijacker).Hijack()
+	if err == nil {
+		tw.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// push implements http.Pusher for the writers wrapping one.
+func (tw *timeoutWriter) push(target string, opts *http.PushOptions) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.expired() {
+		return http.ErrHandlerTimeout
+	}
+
+	return tw.w.(http.Pusher).Push(target, opts)
+}
+
+// timeoutHijackWriter is a timeoutWriter wrapping an http.Hijacker.
+type timeoutHijackWriter struct {
+	*timeoutWriter
+}
+
+// Hijack implements http.Hijacker
+func (w timeoutHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// timeoutPushWriter is a timeoutWriter wrapping an http.Pusher.
+type timeoutPushWriter struct {
+	*timeoutWriter
+}
+
+// Push implements http.Pusher
+func (w timeoutPushWriter) Push(target string, opts *http.PushOptions) error {
+	return w.push(target, opts)
+}
+
+// timeoutHijackPushWriter is a timeoutWriter wrapping an http.Hijacker and http.Pusher.
+type timeoutHijackPushWriter struct {
+	*timeoutWriter
+}
+
+// Hijack implements http.Hijacker
+func (w timeoutHijackPushWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.hijack()
+}
+
+// Push implements http.Pusher
+func (w timeoutHijackPushWriter) Push(target string, opts *http.PushOptions) error {
+	return w.push(target, opts)
+}
+
+// copyHeader adds all src headers to dst.
+func copyHeader(dst, src http.Header) {
+	for k, vv := range src {
+		dst[k] = append(dst[k][:0:0], vv...)
+	}
+}
@@ -0,0 +1,236 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouteTimeout(t *testing.T) {
+	finished := make(chan error, 1)
+
+	r := New("/")
+	r.Timeout(time.Second)
+	r.Add("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Slow", "true")
+		<-r.Context().Done()
+
+		// Late writes are rejected
+		_, err := w.Write([]byte("late"))
+		finished <- err
+	})).Timeout(10 * time.Millisecond)
+	r.Add("/fast", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("Request context should have the router deadline")
+		}
+		w.Header().Set("X-Fast", "true")
+	}))
+
+	d := Build(r)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Response status should be 503. Got %d", w.Code)
+	}
+	if w.Header().Get("X-Slow") != "" {
+		t.Error("Handler headers shouldn't be sent on timeout")
+	}
+	if err := <-finished; err != http.ErrHandlerTimeout {
+		t.Errorf("Writes after the timeout should fail with ErrHandlerTimeout. Got %v", err)
+	}
+	if w.Body.String() != "Service Unavailable\n" {
+		t.Errorf("Response body shouldn't be corrupted by late writes. Got %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Response status should be 200. Got %d", w.Code)
+	}
+	if w.Header().Get("X-Fast") != "true" {
+		t.Error("Handler headers should be sent when not writing a body")
+	}
+}
+
+func TestRouteTimeoutCanceled(t *testing.T) {
+	started := make(chan struct{})
+	finished := make(chan error, 1)
+
+	r := New("/")
+	r.Timeout(time.Second)
+	r.Add("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+
+		// Writes after the client went away, and after ServeHTTP returned, are rejected
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("X-Late", "true")
+		_, err := w.Write([]byte("late"))
+		finished <- err
+	}))
+
+	d := Build(r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil).WithContext(ctx))
+
+	if err := <-finished; err != http.ErrHandlerTimeout {
+		t.Errorf("Writes after the request context is canceled should fail with http.ErrHandlerTimeout. Got %v", err)
+	}
+	if w.Body.String() != "" || w.Header().Get("X-Late") != "" {
+		t.Errorf("Nothing should be written after the request context is canceled. Got %v %s", w.Header(), w.Body.String())
+	}
+}
+
+func TestRouteTimeoutStarted(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})).Timeout(10 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	Build(r).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Response status shouldn't change once started. Got %d", w.Code)
+	}
+	if w.Body.String() != "partial" {
+		t.Errorf("Response body should be 'partial'. Got %s", w.Body.String())
+	}
+	if !w.Flushed {
+		t.Error("Flush should reach the client before the deadline")
+	}
+}
+
+func TestTimeoutHandler(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})).Timeout(10 * time.Millisecond)
+
+	d := Build(r)
+	d.TimeoutHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGatewayTimeout)
+		w.Write([]byte(`{"error":"timeout"}`))
+	}))
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusGatewayTimeout {
+		t.Errorf("Response status should be 504. Got %d", w.Code)
+	}
+	if w.Body.String() != `{"error":"timeout"}` {
+		t.Errorf("Response body isn't as expected. Got %s", w.Body.String())
+	}
+}
+
+func TestRouteTimeoutPanic(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})).Timeout(time.Second)
+
+	var recovered *Panic
+	d := Build(r)
+	d.Recover(func(w http.ResponseWriter, r *http.Request, p *Panic) {
+		recovered = p
+	}, false)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if recovered == nil || recovered.Value != "boom" {
+		t.Errorf("Panic in a handler with timeout should be recovered. Got %+v", recovered)
+	}
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Response status should be 500. Got %d", w.Code)
+	}
+}
+
+func TestRouteTimeoutMiddleware(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})).Timeout(10 * time.Millisecond)
+
+	var status int
+	d := Build(r)
+	d.Wrap(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if _, ok := r.Context().Deadline(); ok {
+				t.Error("Dispatcher middleware shouldn't be bound by the route timeout")
+			}
+
+			w := WrapWriter(rw)
+			next.ServeHTTP(w, r)
+			status = w.Status()
+		})
+	})
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("Middleware should see the timeout response status. Got %d", status)
+	}
+}
+
+func TestRouteTimeoutPanicStack(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(panickingHandler)).Timeout(time.Second)
+
+	var recovered *Panic
+	d := Build(r)
+	d.Recover(func(w http.ResponseWriter, r *http.Request, p *Panic) {
+		recovered = p
+	}, true)
+
+	d.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if recovered == nil || !strings.Contains(string(recovered.Stack), "panickingHandler") {
+		t.Errorf("Panic stack should be captured where the handler panicked. Got %+v", recovered)
+	}
+}
+
+func panickingHandler(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestRouteTimeoutServer(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetPrincipal(r, "joe")
+		<-r.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		SetPrincipal(r, "late")
+	})).Timeout(10 * time.Millisecond)
+
+	srv := httptest.NewServer(Build(r))
+	defer srv.Close()
+
+	for i := 0; i < 3; i++ {
+		res, err := srv.Client().Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Response status should be 503. Got %d", res.StatusCode)
+		}
+	}
+	time.Sleep(30 * time.Millisecond)
+}
@@ -0,0 +1,140 @@
+package router
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Tracer is implemented by tracing backends (e.g. an OpenTelemetry adapter) to receive the Dispatcher spans.
+//
+// For each request the Dispatcher starts a server span, renamed after the matched route pattern (e.g. "GET /users/:id"),
+// and a "route" child span covering the router matching.
+// The context returned by Start is set on the request, so handlers run inside the server span.
+type Tracer interface {
+	// Start begins a span named name, child of the span found in ctx, if any.
+	// The remote parent parsed from the incoming "traceparent" header is available through SpanContextFromContext.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single operation started by a Tracer.
+type Span interface {
+	// SetName changes the span name.
+	SetName(name string)
+
+	// SetAttribute sets an attribute on the span.
+	SetAttribute(key string, value interface{})
+
+	// End completes the span.
+	End()
+}
+
+// SpanContext identifies a span across process boundaries, as defined by W3C Trace Context.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+}
+
+// IsValid reports whether both trace and span IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Sampled reports whether the sampled flag is set.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&0x01 == 0x01
+}
+
+// Traceparent formats the span context as a "traceparent" header value, for propagation to outgoing requests.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%x-%x-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a W3C "traceparent" header value.
+// It reports false if the value is malformed or has invalid IDs.
+func ParseTraceparent(v string) (SpanContext, bool) {
+	var sc SpanContext
+
+	parts := strings.Split(strings.TrimSpace(v), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	// Version 00 has exactly 4 parts, future versions may add more
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	flags := make([]byte, 1)
+	if _, err := hex.Decode(flags, []byte(parts[3])); err != nil {
+		return sc, false
+	}
+	sc.Flags = flags[0]
+
+	return sc, sc.IsValid()
+}
+
+type spanContextKey struct{}
+
+// SpanContextFromContext returns the remote parent span context parsed from the incoming "traceparent" header.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+
+	return sc, ok
+}
+
+// startSpan starts the server span for the request, after extracting the remote parent, and sets its context on the request.
+func (d *dispatcher) startSpan(req *http.Request) Span {
+	ctx := req.Context()
+	if sc, ok := ParseTraceparent(req.Header.Get("traceparent")); ok {
+		ctx = context.WithValue(ctx, spanContextKey{}, sc)
+	}
+
+	ctx, span := d.tracer.Start(ctx, "HTTP "+req.Method)
+	span.SetAttribute("http.method", req.Method)
+	*req = *req.WithContext(ctx)
+
+	return span
+}
+
+// nameSpan renames the server span after the matched route and sets the route attributes.
+func nameSpan(span Span, req *http.Request) {
+	pattern := Pattern(req)
+	if pattern == "" {
+		return
+	}
+
+	span.SetName(req.Method + " " + pattern)
+	span.SetAttribute("http.route", pattern)
+	for k, v := range Params(req) {
+		span.SetAttribute("http.route.param."+k, v)
+	}
+}
+
+// endSpan sets the response status and ends the server span.
+// It's deferred by ServeHTTP, so it also records panics before passing them on to the recovery.
+func endSpan(span Span, w *responseWriter) {
+	if v := recover(); v != nil {
+		span.SetAttribute("http.status_code", http.StatusInternalServerError)
+		span.SetAttribute("panic", fmt.Sprint(v))
+		span.End()
+		panic(v)
+	}
+
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	span.SetAttribute("http.status_code", status)
+	span.End()
+}
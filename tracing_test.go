@@ -0,0 +1,147 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// memoryTracer records finished spans in memory.
+type memoryTracer struct {
+	mu    sync.Mutex
+	spans []*memorySpan
+}
+
+type memorySpanKey struct{}
+
+type memorySpan struct {
+	tracer *memoryTracer
+	name   string
+	parent *memorySpan
+	remote SpanContext
+	attrs  map[string]interface{}
+}
+
+func (t *memoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &memorySpan{tracer: t, name: name, attrs: make(map[string]interface{})}
+	if p, ok := ctx.Value(memorySpanKey{}).(*memorySpan); ok {
+		s.parent = p
+	} else if sc, ok := SpanContextFromContext(ctx); ok {
+		s.remote = sc
+	}
+
+	return context.WithValue(ctx, memorySpanKey{}, s), s
+}
+
+func (s *memorySpan) SetName(name string) {
+	s.name = name
+}
+
+func (s *memorySpan) SetAttribute(key string, value interface{}) {
+	s.attrs[key] = value
+}
+
+func (s *memorySpan) End() {
+	s.tracer.mu.Lock()
+	s.tracer.spans = append(s.tracer.spans, s)
+	s.tracer.mu.Unlock()
+}
+
+func TestTrace(t *testing.T) {
+	var handlerSpan *memorySpan
+
+	r := New("/")
+	r.Add("/users/:id", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan, _ = r.Context().Value(memorySpanKey{}).(*memorySpan)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	tracer := &memoryTracer{}
+	d := Build(r)
+	d.Trace(tracer)
+
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	d.ServeHTTP(httptest.NewRecorder(), req)
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Tracer should have recorded 2 spans. Got %d", len(tracer.spans))
+	}
+
+	routing, server := tracer.spans[0], tracer.spans[1]
+	if routing.name != "route" || routing.parent != server {
+		t.Errorf("First span should be the routing span, child of the server span. Got %s", routing.name)
+	}
+	if server.name != "GET /users/:id" {
+		t.Errorf("Server span should be named after the route. Got %s", server.name)
+	}
+	if handlerSpan != server {
+		t.Error("Handler should run inside the server span")
+	}
+
+	for k, v := range map[string]interface{}{
+		"http.method":         "GET",
+		"http.route":          "/users/:id",
+		"http.route.param.id": "42",
+		"http.status_code":    http.StatusAccepted,
+	} {
+		if server.attrs[k] != v {
+			t.Errorf("Server span attribute %s should be %v. Got %v", k, v, server.attrs[k])
+		}
+	}
+
+	if server.remote.Traceparent() != "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01" {
+		t.Errorf("Server span should have the remote parent from traceparent. Got %s", server.remote.Traceparent())
+	}
+}
+
+func TestTracePanic(t *testing.T) {
+	r := New("/")
+	r.Add("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	tracer := &memoryTracer{}
+	d := Build(r)
+	d.Trace(tracer)
+	d.Recover(nil, false)
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Response status should be 500. Got %d", w.Code)
+	}
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Tracer should have recorded 2 spans. Got %d", len(tracer.spans))
+	}
+	if tracer.spans[1].attrs["panic"] != "boom" {
+		t.Errorf("Server span should record the panic. Got %v", tracer.spans[1].attrs["panic"])
+	}
+}
+
+func TestParseTraceparent(t *testing.T) {
+	sc, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok {
+		t.Fatal("Valid traceparent should be parsed")
+	}
+	if !sc.Sampled() {
+		t.Error("Span context should be sampled")
+	}
+
+	for _, v := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, ok := ParseTraceparent(v); ok {
+			t.Errorf("Invalid traceparent '%s' shouldn't be parsed", v)
+		}
+	}
+}
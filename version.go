@@ -0,0 +1,143 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type versionKey struct{}
+
+// APIVersion returns the API version resolved by the Dispatcher for the current request.
+// It's empty if the request wasn't dispatched to a versioned Router.
+func APIVersion(req *http.Request) string {
+	if v, ok := req.Context().Value(versionKey{}).(string); ok {
+		return v
+	}
+
+	return ""
+}
+
+// Version groups the routers declared for an API version in a Dispatcher.
+type Version struct {
+	// Version name without the "v" prefix, e.g. "2"
+	name string
+
+	// Routers serving this version
+	routes []Router
+
+	// Deprecation settings, since is zero if not deprecated
+	since  time.Time
+	sunset time.Time
+}
+
+// Deprecate marks the version as deprecated since the given date, which is required.
+// Responses get an RFC 9745 "Deprecation" header with that date (e.g. "@1767225600")
+// and, if sunset isn't zero, a "Sunset" header with that date.
+func (v *Version) Deprecate(since, sunset time.Time) *Version {
+	if since.IsZero() {
+		panic("router: deprecation date required")
+	}
+	v.since = since
+	v.sunset = sunset
+
+	return v
+}
+
+// setHeaders adds the deprecation headers to the response, if any.
+func (v *Version) setHeaders(w http.ResponseWriter) {
+	if v.since.IsZero() {
+		return
+	}
+
+	w.Header().Set("Deprecation", "@"+strconv.FormatInt(v.since.Unix(), 10))
+	if !v.sunset.IsZero() {
+		w.Header().Set("Sunset", v.sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// versioning holds the Dispatcher API versions configuration.
+type versioning struct {
+	// Declared versions
+	versions map[string]*Version
+
+	// Version used when the request doesn't declare any
+	fallback string
+
+	// Matches vendor media types such as "application/vnd.acme.v2+json"
+	mediaType *regexp.Regexp
+}
+
+// versionName removes the optional "v" prefix from a version.
+func versionName(v string) string {
+	if len(v) > 1 && (v[0] == 'v' || v[0] == 'V') {
+		return v[1:]
+	}
+
+	return v
+}
+
+// resolve finds the version requested and reports whether it came from the path.
+// The first path part ("/v2/...") takes precedence over the Accept header vendor media type,
+// and the default version is used when none of them declares a known version.
+func (vs *versioning) resolve(req *http.Request) (*Version, bool) {
+	if len(vs.versions) == 0 {
+		return nil, false
+	}
+
+	// Path
+	part := strings.TrimPrefix(req.URL.Path, "/")
+	if i := strings.IndexByte(part, '/'); i >= 0 {
+		part = part[:i]
+	}
+	if len(part) > 1 && (part[0] == 'v' || part[0] == 'V') {
+		if v, ok := vs.versions[part[1:]]; ok {
+			return v, true
+		}
+	}
+
+	// Accept header
+	if vs.mediaType != nil {
+		// Highest q-value wins, refused (q=0) versions are skipped
+		var (
+			best  *Version
+			bestQ float64
+		)
+		for _, av := range parseAccept(req.Header.Get("Accept")) {
+			if m := vs.mediaType.FindStringSubmatch(av.value); m != nil && av.q > bestQ {
+				if v, ok := vs.versions[m[1]]; ok {
+					best, bestQ = v, av.q
+				}
+			}
+		}
+		if best != nil {
+			return best, false
+		}
+	}
+
+	// Default
+	if v, ok := vs.versions[vs.fallback]; ok {
+		return v, false
+	}
+
+	return nil, false
+}
+
+// stripVersion removes the version part from the request path.
+func stripVersion(req *http.Request) {
+	p := strings.TrimPrefix(req.URL.Path, "/")
+	if i := strings.IndexByte(p, '/'); i >= 0 {
+		req.URL.Path = p[i:]
+	} else {
+		req.URL.Path = "/"
+	}
+	req.URL.RawPath = ""
+}
+
+// setVersion stores the resolved version in the request context.
+func setVersion(req *http.Request, v *Version) {
+	*req = *req.WithContext(context.WithValue(req.Context(), versionKey{}, v.name))
+}
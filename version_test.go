@@ -0,0 +1,102 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func versionHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(APIVersion(r) + " " + r.URL.Path + " " + Param(r, "id")))
+}
+
+func versionDispatcher() Dispatcher {
+	v1 := New("/")
+	v1.Add("/users/:id", http.HandlerFunc(versionHandler))
+
+	v2 := New("/")
+	v2.Add("/users/:id", http.HandlerFunc(versionHandler))
+
+	other := New("/")
+	other.Add("/v3/users/:id", http.HandlerFunc(versionHandler))
+
+	d := Build(other)
+	d.Versioning("acme", "2")
+	d.Version("v1", v1).
+		Deprecate(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+	d.Version("2", v2)
+
+	return d
+}
+
+func TestVersionFromPath(t *testing.T) {
+	d := versionDispatcher()
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/v1/users/42", nil))
+	if w.Body.String() != "1 /users/42 42" {
+		t.Errorf("Response body should be '1 /users/42 42'. Got %s", w.Body.String())
+	}
+	if w.Header().Get("Deprecation") != "@1767225600" {
+		t.Errorf("Deprecation header should be '@1767225600'. Got %s", w.Header().Get("Deprecation"))
+	}
+	if w.Header().Get("Sunset") != "Tue, 01 Jan 2030 00:00:00 GMT" {
+		t.Errorf("Sunset header isn't as expected. Got %s", w.Header().Get("Sunset"))
+	}
+
+	w = httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/v2/users/42", nil))
+	if w.Body.String() != "2 /users/42 42" {
+		t.Errorf("Response body should be '2 /users/42 42'. Got %s", w.Body.String())
+	}
+	if w.Header().Get("Deprecation") != "" {
+		t.Errorf("Deprecation header shouldn't be set for v2. Got %s", w.Header().Get("Deprecation"))
+	}
+}
+
+func TestVersionFromAccept(t *testing.T) {
+	d := versionDispatcher()
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req.Header.Set("Accept", "application/vnd.acme.v1+json")
+	d.ServeHTTP(w, req)
+	if w.Body.String() != "1 /users/42 42" {
+		t.Errorf("Response body should be '1 /users/42 42'. Got %s", w.Body.String())
+	}
+
+	for accept, body := range map[string]string{
+		"application/vnd.acme.v1+json;q=0.5, application/vnd.acme.v2+json": "2 /users/42 42",
+		"application/vnd.acme.v2+json;q=0, application/vnd.acme.v1+json":   "1 /users/42 42",
+	} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/users/42", nil)
+		req.Header.Set("Accept", accept)
+		d.ServeHTTP(w, req)
+		if w.Body.String() != body {
+			t.Errorf("Accept %s response body should be '%s'. Got %s", accept, body, w.Body.String())
+		}
+	}
+}
+
+func TestVersionDefault(t *testing.T) {
+	d := versionDispatcher()
+
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/users/42", nil))
+	if w.Body.String() != "2 /users/42 42" {
+		t.Errorf("Response body should be '2 /users/42 42'. Got %s", w.Body.String())
+	}
+}
+
+func TestVersionFallThrough(t *testing.T) {
+	d := versionDispatcher()
+
+	// Undeclared version goes to unversioned routers with the path untouched
+	w := httptest.NewRecorder()
+	d.ServeHTTP(w, httptest.NewRequest("GET", "/v3/users/42", nil))
+	if w.Body.String() != " /v3/users/42 42" {
+		t.Errorf("Response body should be ' /v3/users/42 42'. Got %s", w.Body.String())
+	}
+}
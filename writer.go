@@ -0,0 +1,251 @@
+package router
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// ResponseWriter is an http.ResponseWriter that keeps track of the response status and size,
+// meant to be used by Middleware that need to inspect the response.
+// It implements http.Flusher and io.ReaderFrom by delegating to the wrapped writer,
+// degrading gracefully when it doesn't support them, so wrapping doesn't break streaming or sendfile optimizations.
+// It also implements http.Hijacker and http.Pusher, but only when the wrapped writer does,
+// so feature checks like w.(http.Hijacker) keep working.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	io.ReaderFrom
+
+	// Status returns the response status code, or 0 if headers weren't written yet.
+	Status() int
+
+	// Size returns the number of body bytes written.
+	Size() int64
+
+	// Unwrap returns the wrapped writer, for http.ResponseController.
+	Unwrap() http.ResponseWriter
+}
+
+// WrapWriter returns a ResponseWriter for w.
+// If w is already a ResponseWriter created by this package, it's returned as is,
+// so wrapping at several middleware levels is cheap.
+func WrapWriter(w http.ResponseWriter) ResponseWriter {
+	return wrapWriter(w).writer()
+}
+
+// responseWriter implements ResponseWriter
+type responseWriter struct {
+	http.ResponseWriter
+
+	// Response status code, 0 until headers are written
+	status int
+
+	// Body bytes written
+	size int64
+
+	// Writer passed on to handlers, implementing the optional interfaces the wrapped writer implements
+	exposed ResponseWriter
+}
+
+// wrapWriter returns the *responseWriter behind w, wrapping it only if it isn't one already.
+func wrapWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(interface{ core() *responseWriter }); ok {
+		return rw.core()
+	}
+
+	rw := &responseWriter{ResponseWriter: w}
+	_, hijacker := w.(http.Hijacker)
+	_, pusher := w.(http.Pusher)
+
+	switch {
+	case hijacker && pusher:
+		rw.exposed = hijackPushWriter{rw}
+	case hijacker:
+		rw.exposed = hijackWriter{rw}
+	case pusher:
+		rw.exposed = pushWriter{rw}
+	default:
+		rw.exposed = rw
+	}
+
+	return rw
+}
+
+// core returns the *responseWriter itself, also through the writers embedding it.
+func (w *responseWriter) core() *responseWriter {
+	return w
+}
+
+// writer returns the ResponseWriter to pass on to handlers.
+func (w *responseWriter) writer() ResponseWriter {
+	return w.exposed
+}
+
+// Status implements ResponseWriter
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+// Size implements ResponseWriter
+func (w *responseWriter) Size() int64 {
+	return w.size
+}
+
+// WriteHeader implements http.ResponseWriter
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Flush implements http.Flusher. It's a no-op if the wrapped writer isn't a Flusher.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// ReadFrom implements io.ReaderFrom, so the wrapped writer optimizations (e.g. sendfile) are kept.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	var (
+		n   int64
+		err error
+	)
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(r)
+	} else {
+		n, err = io.Copy(w.ResponseWriter, r)
+	}
+	w.size += n
+
+	return n, err
+}
+
+// Unwrap implements ResponseWriter
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// hijackWriter is a *responseWriter wrapping an http.Hijacker.
+type hijackWriter struct {
+	*responseWriter
+}
+
+// Hijack implements http.Hijacker
+func (w hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// pushWriter is a *responseWriter wrapping an http.Pusher.
+type pushWriter struct {
+	*responseWriter
+}
+
+// Push implements http.Pusher
+func (w pushWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// hijackPushWriter is a *responseWriter wrapping an http.Hijacker and http.Pusher.
+type hijackPushWriter struct {
+	*responseWriter
+}
+
+// Hijack implements http.Hijacker
+func (w hijackPushWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+// Push implements http.Pusher
+func (w hijackPushWriter) Push(target string, opts *http.PushOptions) error {
+	return w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+// wrappedWriter is a middleware writer buffering or transforming the response of the writer it wraps.
+type wrappedWriter interface {
+	http.ResponseWriter
+	http.Flusher
+
+	// Unwrap returns the wrapped writer, for http.ResponseController.
+	Unwrap() http.ResponseWriter
+
+	// hijacked is called before the connection is taken over, so nothing else is written.
+	hijacked()
+}
+
+// exposeWrapped returns w implementing http.Hijacker and http.Pusher only when the writer it wraps does,
+// so feature checks like w.(http.Hijacker) keep working through middleware writers.
+func exposeWrapped(w wrappedWriter) http.ResponseWriter {
+	_, hijacker := w.Unwrap().(http.Hijacker)
+	_, pusher := w.Unwrap().(http.Pusher)
+
+	switch {
+	case hijacker && pusher:
+		return wrappedHijackPushWriter{w}
+	case hijacker:
+		return wrappedHijackWriter{w}
+	case pusher:
+		return wrappedPushWriter{w}
+	}
+
+	return w
+}
+
+// wrappedHijackWriter is a wrappedWriter wrapping an http.Hijacker.
+type wrappedHijackWriter struct {
+	wrappedWriter
+}
+
+// Hijack implements http.Hijacker
+func (w wrappedHijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked()
+	return w.Unwrap().(http.Hijacker).Hijack()
+}
+
+// wrappedPushWriter is a wrappedWriter wrapping an http.Pusher.
+type wrappedPushWriter struct {
+	wrappedWriter
+}
+
+// Push implements http.Pusher
+func (w wrappedPushWriter) Push(target string, opts *http.PushOptions) error {
+	return w.Unwrap().(http.Pusher).Push(target, opts)
+}
+
+// wrappedHijackPushWriter is a wrappedWriter wrapping an http.Hijacker and http.Pusher.
+type wrappedHijackPushWriter struct {
+	wrappedWriter
+}
+
+// Hijack implements http.Hijacker
+func (w wrappedHijackPushWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked()
+	return w.Unwrap().(http.Hijacker).Hijack()
+}
+
+// Push implements http.Pusher
+func (w wrappedHijackPushWriter) Push(target string, opts *http.PushOptions) error {
+	return w.Unwrap().(http.Pusher).Push(target, opts)
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := wrapWriter(rec)
+
+	if wrapWriter(w) != w {
+		t.Error("wrapWriter shouldn't wrap a *responseWriter twice")
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Hello"))
+	w.ReadFrom(strings.NewReader(" world"))
+
+	if w.status != http.StatusCreated {
+		t.Errorf("Status should be 201. Got %d", w.status)
+	}
+	if w.size != 11 {
+		t.Errorf("Size should be 11. Got %d", w.size)
+	}
+	if rec.Body.String() != "Hello world" {
+		t.Errorf("Body should be 'Hello world'. Got %s", rec.Body.String())
+	}
+
+	w.Flush()
+	if !rec.Flushed {
+		t.Error("Flush should have been passed to the wrapped writer")
+	}
+
+	if w.Unwrap() != rec {
+		t.Error("Unwrap should return the wrapped writer")
+	}
+}
+
+func TestResponseWriterImplicitStatus(t *testing.T) {
+	w := wrapWriter(httptest.NewRecorder())
+	w.Write([]byte("Hello"))
+
+	if w.status != http.StatusOK {
+		t.Errorf("Status should be 200 after writing the body. Got %d", w.status)
+	}
+}
+
+// hijackRecorder is a ResponseRecorder implementing http.Hijacker.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
+
+func TestResponseWriterInterfaces(t *testing.T) {
+	w := WrapWriter(httptest.NewRecorder())
+	if _, ok := w.(http.Hijacker); ok {
+		t.Error("ResponseWriter shouldn't implement http.Hijacker if the wrapped writer doesn't")
+	}
+	if _, ok := w.(http.Pusher); ok {
+		t.Error("ResponseWriter shouldn't implement http.Pusher if the wrapped writer doesn't")
+	}
+
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	w = WrapWriter(rec)
+	h, ok := w.(http.Hijacker)
+	if !ok {
+		t.Fatal("ResponseWriter should implement http.Hijacker if the wrapped writer does")
+	}
+	if _, ok := w.(http.Pusher); ok {
+		t.Error("ResponseWriter shouldn't implement http.Pusher if the wrapped writer doesn't")
+	}
+
+	h.Hijack()
+	if !rec.hijacked {
+		t.Error("Hijack should be passed to the wrapped writer")
+	}
+
+	if WrapWriter(w) != w {
+		t.Error("WrapWriter shouldn't wrap a ResponseWriter twice")
+	}
+}
+
+// testHijacker checks that the writer handlers get implements http.Hijacker only when the server writer does,
+// and that Hijack reaches it. wrap returns the handler under test calling the given one, requests come from newReq.
+func testHijacker(t *testing.T, wrap func(http.Handler) http.Handler, newReq func() *http.Request) {
+	t.Helper()
+
+	var (
+		ok  bool
+		err error
+	)
+	h := wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var hj http.Hijacker
+		if hj, ok = w.(http.Hijacker); ok {
+			_, _, err = hj.Hijack()
+		}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), newReq())
+	if ok {
+		t.Error("Writer shouldn't implement http.Hijacker if the server writer doesn't")
+	}
+
+	rec := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(rec, newReq())
+	if !ok || err != nil || !rec.hijacked {
+		t.Errorf("Writer should pass Hijack to the server writer. Got %t %v", rec.hijacked, err)
+	}
+	if rec.Body.Len() != 0 {
+		t.Errorf("Nothing should be written after hijacking. Got %s", rec.Body.String())
+	}
+}